@@ -0,0 +1,147 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/hms"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+//this action computes the watershed average precipitation depth of every storm at every candidate location of the uniform fishnet.
+//the depth over location grids are the base input for importance sampling and show which placements matter hydrologically.
+
+// BasinAverageDepthResult is the basin average depth at each candidate location for a storm, depths are NaN if part of the
+// watershed is outside the storm raster or nodata.
+type BasinAverageDepthResult struct {
+	StormName string
+	Locations utils.CoordinateList
+	Depths    []float64
+	Grid      utils.DepthRaster //only available for the square fishnet pattern.
+}
+
+// ToBytes writes a csv with x,y,basin_average_depth rows, missing depths are empty.
+func (bad BasinAverageDepthResult) ToBytes() []byte {
+	data := "x,y,basin_average_depth"
+	for i, l := range bad.Locations.Coordinates {
+		depth := ""
+		if !math.IsNaN(bad.Depths[i]) {
+			depth = fmt.Sprint(bad.Depths[i])
+		}
+		data = fmt.Sprintf("%v\n%v,%v,%v", data, l.X, l.Y, depth)
+	}
+	return []byte(data)
+}
+
+// stormDepth reads the total depth raster for a storm from <root>/<storm date>.tif, the storm date is the second word of the storm name.
+func stormDepth(root string, storm hms.PrecipGridEvent) (utils.DepthRaster, error) {
+	words := strings.Split(storm.Name, " ")
+	if len(words) < 2 {
+		return utils.DepthRaster{}, fmt.Errorf("could not determine the storm date for %v", storm.Name)
+	}
+	field, err := utils.ReadPrecipitationField(fmt.Sprintf("%v/%v.tif", root, words[1]))
+	if err != nil {
+		return utils.DepthRaster{}, err
+	}
+	depth, err := field.TotalDepth()
+	if err != nil {
+		return depth, fmt.Errorf("could not read the total depth for %v: %v", storm.Name, err)
+	}
+	return depth, nil
+}
+
+// BasinAverageDepths computes the basin average depth for every storm at every candidate location with the raster placement engine.
+func (sc StratifiedCompute) BasinAverageDepths(inputRoot cc.DataSource) ([]BasinAverageDepthResult, error) {
+	results := make([]BasinAverageDepthResult, 0, len(sc.GridFile.Events))
+	candidates, err := sc.generateStormCenters()
+	if err != nil {
+		return results, err
+	}
+	if len(candidates.Coordinates) == 0 {
+		return results, errors.New("the transposition region does not contain any candidate locations")
+	}
+	root := path.Dir(inputRoot.Paths["default"])
+	var footprint utils.WatershedFootprint
+	var firstDepth utils.DepthRaster
+	for i, storm := range sc.GridFile.Events {
+		depth, err := stormDepth(root, storm)
+		if err != nil {
+			return results, err
+		}
+		if i == 0 {
+			//storm rasters share a grid so the footprint is computed once.
+			footprint, err = utils.NewWatershedFootprint(sc.StudyArea, depth)
+			if err != nil {
+				return results, err
+			}
+			firstDepth = depth
+		} else if err = depth.SameGrid(firstDepth); err != nil {
+			return results, fmt.Errorf("storm %v: %v", storm.Name, err)
+		}
+		_, stormCandidates, err := sc.stormCandidates(candidates, storm)
+		if err != nil {
+			return results, err
+		}
+		stats := depth.PlaceAll(footprint, utils.Coordinate{X: storm.CenterX, Y: storm.CenterY}, stormCandidates.Coordinates, sc.AcceptanceDepthThreshold)
+		depths := make([]float64, len(stats))
+		for j, s := range stats {
+			depths[j] = s.Mean
+			if s.Missing > 0 {
+				depths[j] = math.NaN()
+			}
+		}
+		var grid utils.DepthRaster
+		if sc.Pattern == utils.SquareFishnet && len(stormCandidates.Coordinates) > 0 {
+			//the other patterns are not aligned to a grid.
+			grid, err = utils.LocationGrid(stormCandidates.Coordinates, depths, sc.Spacing, depth.Projection)
+			if err != nil {
+				return results, err
+			}
+		}
+		results = append(results, BasinAverageDepthResult{StormName: storm.Name, Locations: stormCandidates, Depths: depths, Grid: grid})
+	}
+	return results, nil
+}
+
+// PutBasinAverageDepths writes <storm name>.tif (if the result has a grid) and <storm name>.csv for each storm to the BasinAverageDepths output.
+func PutBasinAverageDepths(results []BasinAverageDepthResult, iomanager cc.IOManager) error {
+	outputDataSource, err := iomanager.GetOutputDataSource("BasinAverageDepths")
+	if err != nil {
+		return errors.New("could not find the BasinAverageDepths output datasource")
+	}
+	root := outputDataSource.Paths["default"]
+	err = os.MkdirAll(LOCALDIR, 0755)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Grid.Values != nil {
+			localPath := fmt.Sprintf("%v%v.tif", LOCALDIR, r.StormName)
+			err = utils.WriteDepthRaster(r.Grid, localPath)
+			if err != nil {
+				return err
+			}
+			tifBytes, err := os.ReadFile(localPath)
+			if err != nil {
+				return err
+			}
+			os.Remove(localPath)
+			outputDataSource.Paths["default"] = fmt.Sprintf("%v/%v.tif", root, r.StormName)
+			err = utils.PutFile(tifBytes, iomanager, outputDataSource, "default")
+			if err != nil {
+				return err
+			}
+		}
+		outputDataSource.Paths["default"] = fmt.Sprintf("%v/%v.csv", root, r.StormName)
+		err = utils.PutFile(r.ToBytes(), iomanager, outputDataSource, "default")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/hms"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+/*
+This action builds the antecedent condition basin library that full_simulation_sst samples basin paths from.
+//steps:
+1. read a template basin for each calibration event
+2. read the table of initial conditions by date
+3. for each date and calibration event update the template with the initial conditions for the date
+4. write the basins as <root>/<yyyy-mm-dd>_<basin>_<calibrationEvent>.<extension>
+5. write an index of the library and a report of dates and calibration events missing from the por range
+*/
+type BuildAntecedentLibraryAction struct {
+	action cc.Action
+}
+
+// AntecedentLibraryResult summarizes the library, missing dates are days in the por range without initial conditions
+// and missing calibration events are calibration events without a template basin.
+type AntecedentLibraryResult struct {
+	Index                    utils.AntecedentLibraryIndex
+	MissingDates             []time.Time
+	MissingCalibrationEvents []string
+}
+
+func InitBuildAntecedentLibraryAction(a cc.Action) *BuildAntecedentLibraryAction {
+	return &BuildAntecedentLibraryAction{action: a}
+}
+func (bal *BuildAntecedentLibraryAction) Compute(pm *cc.PluginManager) (AntecedentLibraryResult, error) {
+	a := bal.action
+	var result AntecedentLibraryResult
+	basinName := a.Attributes.GetStringOrFail("basin_name")
+	basinExtension := a.Attributes.GetStringOrDefault("basin_extension", "basin")
+	calibrationEventNames, err := a.Attributes.GetStringSlice("calibration_event_names")
+	if err != nil {
+		return result, err
+	}
+	porStart, err := time.Parse("20060102", a.Attributes.GetStringOrFail("por_start_date"))
+	if err != nil {
+		return result, err
+	}
+	porEnd, err := time.Parse("20060102", a.Attributes.GetStringOrFail("por_end_date"))
+	if err != nil {
+		return result, err
+	}
+	failOnMissing := a.Attributes.GetBooleanOrDefault("fail_on_missing", false)
+	output, err := a.GetOutputDataSource(a.Attributes.GetStringOrFail("output_data_source"))
+	if err != nil {
+		return result, err
+	}
+	root := output.Paths["default"]
+
+	//initial conditions by date
+	initialConditionsStore := a.Attributes.GetStringOrFail("initial_conditions_store")
+	initialConditionsBytes, err := getStoreFile(pm, initialConditionsStore, a.Attributes.GetStringOrFail("initial_conditions_file"))
+	if err != nil {
+		return result, err
+	}
+	table, err := utils.InitialConditionsFromBytes(initialConditionsBytes)
+	if err != nil {
+		return result, err
+	}
+	//template basins by calibration event, named <calibrationEvent>.<extension>
+	templateStore := a.Attributes.GetStringOrFail("template_basin_store")
+	templateDirectory := a.Attributes.GetStringOrFail("template_basin_directory")
+	templates := make(map[string]hms.Basin)
+	for _, ce := range calibrationEventNames {
+		templateBytes, err := getStoreFile(pm, templateStore, fmt.Sprintf("%v/%v.%v", templateDirectory, ce, basinExtension))
+		if err != nil {
+			result.MissingCalibrationEvents = append(result.MissingCalibrationEvents, ce)
+			continue
+		}
+		template, err := hms.ReadBasin(templateBytes)
+		if err != nil {
+			return result, fmt.Errorf("could not read the template basin for %v: %v", ce, err)
+		}
+		templates[ce] = template
+	}
+	result.MissingDates = table.MissingDates(porStart, porEnd)
+	for _, ce := range result.MissingCalibrationEvents {
+		pm.Logger.Error(fmt.Sprintf("calibration event %v does not have a template basin", ce))
+	}
+	if len(result.MissingDates) > 0 {
+		pm.Logger.Error(fmt.Sprintf("%v days from %v to %v do not have initial conditions", len(result.MissingDates), porStart.Format("20060102"), porEnd.Format("20060102")))
+	}
+	if failOnMissing && (len(result.MissingDates) > 0 || len(result.MissingCalibrationEvents) > 0) {
+		return result, fmt.Errorf("the antecedent condition library is incomplete, see the missing report")
+	}
+
+	//generate the library
+	for _, date := range table.Dates() {
+		conditions := table[date.Format(utils.AntecedentBasinDateFormat)]
+		for _, ce := range calibrationEventNames {
+			template, ok := templates[ce]
+			if !ok {
+				continue
+			}
+			basin := template.Copy()
+			for _, ic := range conditions {
+				err = basin.UpdateElementParameter(ic.Element, ic.Parameter, ic.Value)
+				if err != nil {
+					return result, fmt.Errorf("could not apply initial conditions for %v to calibration event %v: %v", date.Format(utils.AntecedentBasinDateFormat), ce, err)
+				}
+			}
+			ab := utils.AntecedentBasin{Date: date, BasinName: basinName, CalibrationEvent: ce}
+			err = putStoreFile(pm, output.StoreName, fmt.Sprintf("%v.%v", ab.Path(root), basinExtension), basin.ToBytes())
+			if err != nil {
+				return result, err
+			}
+			result.Index = append(result.Index, ab)
+		}
+	}
+	pm.Logger.Info(fmt.Sprintf("wrote %v basins to %v", len(result.Index), root))
+	err = putStoreFile(pm, output.StoreName, fmt.Sprintf("%v/index.csv", root), result.Index.ToBytes(root))
+	if err != nil {
+		return result, err
+	}
+	return result, putStoreFile(pm, output.StoreName, fmt.Sprintf("%v/missing.csv", root), result.missingReport())
+}
+
+// missingReport is a csv with date,calibration_event rows, an empty column applies to all dates or calibration events.
+func (alr AntecedentLibraryResult) missingReport() []byte {
+	var data strings.Builder
+	data.WriteString("date,calibration_event")
+	for _, d := range alr.MissingDates {
+		fmt.Fprintf(&data, "\n%v,", d.Format(utils.AntecedentBasinDateFormat))
+	}
+	for _, ce := range alr.MissingCalibrationEvents {
+		fmt.Fprintf(&data, "\n,%v", ce)
+	}
+	return []byte(data.String())
+}
+
+// getStoreFile reads a file by path from a store.
+func getStoreFile(pm *cc.PluginManager, storeName string, filePath string) ([]byte, error) {
+	ds := cc.DataSource{
+		Name:      filePath,
+		ID:        &uuid.NameSpaceDNS,
+		Paths:     map[string]string{"default": filePath},
+		StoreName: storeName,
+	}
+	return utils.GetFile(*pm, ds, "default")
+}
+
+// putStoreFile writes a file by path to a store.
+func putStoreFile(pm *cc.PluginManager, storeName string, filePath string, data []byte) error {
+	ds := cc.DataSource{
+		Name:      filePath,
+		ID:        &uuid.NameSpaceDNS,
+		Paths:     map[string]string{"default": filePath},
+		StoreName: storeName,
+	}
+	return utils.PutFile(data, pm.IOManager, ds, "default")
+}
@@ -0,0 +1,43 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+// DensityKernelsFromAttributes reads the kernel of each storm type. Kernels are circular with the radius attribute unless major_radii,
+// minor_radii and orientations are provided, each with one value per storm type in the order of stormTypes.
+func DensityKernelsFromAttributes(attributes cc.PayloadAttributes, stormTypes []string) (map[string]utils.DensityKernel, error) {
+	kernels := make(map[string]utils.DensityKernel, len(stormTypes))
+	alpha := attributes.GetFloatOrFail("alpha")
+	_, anisotropic := attributes["major_radii"]
+	var majors, minors, orientations []float64
+	if anisotropic {
+		var err error
+		if majors, err = attributes.GetFloatSlice("major_radii"); err != nil {
+			return kernels, err
+		}
+		if minors, err = attributes.GetFloatSlice("minor_radii"); err != nil {
+			return kernels, err
+		}
+		if orientations, err = attributes.GetFloatSlice("orientations"); err != nil {
+			return kernels, err
+		}
+		if len(majors) != len(stormTypes) || len(minors) != len(stormTypes) || len(orientations) != len(stormTypes) {
+			return kernels, fmt.Errorf("major_radii, minor_radii and orientations must have one value for each of the %v storm types", len(stormTypes))
+		}
+	}
+	for i, st := range stormTypes {
+		kernel := utils.IsotropicDensityKernel(attributes.GetFloatOrDefault("radius", 0), alpha)
+		if anisotropic {
+			kernel = utils.DensityKernel{MajorRadius: majors[i], MinorRadius: minors[i], Orientation: orientations[i], Alpha: alpha}
+		}
+		if err := kernel.Validate(); err != nil {
+			return kernels, fmt.Errorf("the density kernel of storm type %v is invalid: %v", st, err)
+		}
+		kernels[st] = kernel
+	}
+	return kernels, nil
+}
@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/hms"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+//the objective of this action is to onboard a storm catalog without hand digitized storm centers.
+//each storm is read from a raster of its precipitation grids (one band per time step) and the storm center is
+//the depth weighted centroid of the total depth or the cell with the maximum depth over a window.
+//the DSS precipitation grids are not read, the module has no HEC-DSS reader and gdal cannot read DSS, so the DSS Pathname of each grid
+//record is ignored and every storm must be exported to a multiband raster first.
+
+// StormCenterResult is the derived storm center for a precipitation grid.
+type StormCenterResult struct {
+	Name    string
+	Center  utils.Coordinate
+	Derived bool //false if the grid already had a storm center and it was kept.
+}
+
+type DeriveStormCentersAction struct {
+	action   cc.Action
+	gridFile hms.GridFile
+}
+
+func InitDeriveStormCentersAction(action cc.Action, gridFile hms.GridFile) *DeriveStormCentersAction {
+	return &DeriveStormCentersAction{
+		action:   action,
+		gridFile: gridFile,
+	}
+}
+
+// Compute derives the storm centers and returns the updated grid file bytes, rasters are read from <root>/<grid name>.<raster_extension>.
+func (dsca *DeriveStormCentersAction) Compute(root string) ([]byte, []StormCenterResult, error) {
+	a := dsca.action
+	results := make([]StormCenterResult, 0, len(dsca.gridFile.Events))
+	method := utils.StormCenterMethod(a.Attributes.GetStringOrDefault("method", string(utils.CentroidStormCenter)))
+	windowHours := a.Attributes.GetIntOrDefault("window_hours", 0)
+	stepHours := a.Attributes.GetIntOrDefault("time_step_hours", 1)
+	if stepHours < 1 {
+		return nil, results, fmt.Errorf("time_step_hours must be at least 1")
+	}
+	if method == utils.MaxDepthStormCenter && windowHours%stepHours != 0 {
+		return nil, results, fmt.Errorf("window_hours must be a multiple of time_step_hours")
+	}
+	extension := a.Attributes.GetStringOrDefault("raster_extension", "tif")
+	overwrite := a.Attributes.GetBooleanOrDefault("overwrite", false)
+	for i := range dsca.gridFile.Events {
+		e := &dsca.gridFile.Events[i]
+		if e.HasStormCenter() && !overwrite {
+			results = append(results, StormCenterResult{Name: e.Name, Center: utils.Coordinate{X: e.CenterX, Y: e.CenterY}})
+			continue
+		}
+		field, err := utils.ReadPrecipitationField(fmt.Sprintf("%v/%v.%v", root, e.Name, extension))
+		if err != nil {
+			return nil, results, fmt.Errorf("could not read the precipitation grids for %v: %v", e.Name, err)
+		}
+		center, err := field.StormCenter(method, windowHours/stepHours)
+		if err != nil {
+			return nil, results, fmt.Errorf("could not derive a storm center for %v: %v", e.Name, err)
+		}
+		e.UpdateStormCenter(center.X, center.Y)
+		results = append(results, StormCenterResult{Name: e.Name, Center: center, Derived: true})
+	}
+	return dsca.gridFile.CatalogBytes(), results, nil
+}
@@ -0,0 +1,24 @@
+package actions
+
+import (
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+// DomainSelectionsFromAttributes reads the layer and feature filter attributes for the transposition region and watershed boundary
+// geopackages, every feature of the first layer is used if they are not provided. The transposition region can also be buffered and
+// split into domains by storm type.
+func DomainSelectionsFromAttributes(attributes cc.PayloadAttributes) utils.DomainSelections {
+	return utils.DomainSelections{
+		TranspositionRegion: utils.DomainSelection{
+			Layer:          attributes.GetStringOrDefault("transposition_layer", ""),
+			Filter:         attributes.GetStringOrDefault("transposition_filter", ""),
+			Buffer:         attributes.GetFloatOrDefault("transposition_buffer", 0),
+			StormTypeField: attributes.GetStringOrDefault("transposition_storm_type_field", ""),
+		},
+		WatershedBoundary: utils.DomainSelection{
+			Layer:  attributes.GetStringOrDefault("watershed_layer", ""),
+			Filter: attributes.GetStringOrDefault("watershed_filter", ""),
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+)
+
+//the event output columns are the eventstore tags of EventResult, the eventschema tag is the schema version that introduced a column
+//(version 1 if it is omitted). columns are only appended, so a consumer of an older version can read a newer output by column name,
+//and by position for csv outputs. the schema version is selected with the event_schema_version attribute.
+
+const (
+	EventSchemaV1            int = 1 //event_number, storm_path, x, y, storm_type, storm_date and basin_path.
+	EventSchemaV2            int = 2 //adds storm_weight, sampling_weight, simulation_start and simulation_end.
+	EventSchemaV3            int = 3 //adds placement_density, storm_rank, storm_duration_hours, dx and dy.
+	LatestEventSchemaVersion int = EventSchemaV3
+)
+
+const eventSchemaTag string = "eventschema"
+
+// eventColumn is an output column and the position of its field in EventResult.
+type eventColumn struct {
+	name  string
+	field int
+}
+
+// eventColumns returns the columns of a schema version in field order.
+func eventColumns(version int) ([]eventColumn, error) {
+	if version < EventSchemaV1 || version > LatestEventSchemaVersion {
+		return nil, fmt.Errorf("event schema version %v is not supported, use a version from %v to %v", version, EventSchemaV1, LatestEventSchemaVersion)
+	}
+	columns := make([]eventColumn, 0)
+	eventType := reflect.TypeOf(EventResult{})
+	for i := 0; i < eventType.NumField(); i++ {
+		field := eventType.Field(i)
+		name, ok := field.Tag.Lookup(cc.ATTR_STRUCT_TAG)
+		if !ok {
+			continue
+		}
+		fieldVersion := EventSchemaV1
+		if v, ok := field.Tag.Lookup(eventSchemaTag); ok {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse the event schema version of %v: %v", name, err)
+			}
+			fieldVersion = parsed
+		}
+		if fieldVersion <= version {
+			columns = append(columns, eventColumn{name: name, field: i})
+		}
+	}
+	return columns, nil
+}
+
+// eventColumnNames are the names of the columns in order.
+func eventColumnNames(columns []eventColumn) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// toCSV writes the results with a header for the columns.
+func (results FullSimulationResult) toCSV(columns []eventColumn) []byte {
+	var sb strings.Builder
+	sb.WriteString(strings.Join(eventColumnNames(columns), ","))
+	values := make([]string, len(columns))
+	for _, r := range results {
+		v := reflect.ValueOf(r)
+		for i, c := range columns {
+			values[i] = fmt.Sprint(v.Field(c.field).Interface())
+		}
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(values, ","))
+	}
+	return []byte(sb.String())
+}
+
+// schemaArrayAttributes limits the recordset attributes built from the eventstore tags to the columns of the schema.
+func schemaArrayAttributes(attributes cc.ArrayAttrSet, columns []eventColumn) cc.ArrayAttrSet {
+	included := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		included[c.name] = true
+	}
+	filtered := make(cc.ArrayAttrSet, 0, len(columns))
+	for _, a := range attributes {
+		if included[a.AttrName] {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
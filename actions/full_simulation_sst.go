@@ -3,12 +3,17 @@ package actions
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"math/rand"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 
 	"time"
 
 	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/hms"
 	"github.com/usace-cloud-compute/hms-mutator/utils"
 )
 
@@ -16,12 +21,12 @@ import (
 This action will generate a full realization sized set of storms, placements, and antecedent conditions.
 //steps:
 1. read in all storm names (from files api just get the contents of the catalog.)
-2. select a storm with uniform probability
-3. define x and y location (predefine fishnet at 1km or 4km possibly, unique to each storm name.)
+2. select a storm with uniform probability (or by storm weight if a storm weights file is provided)
+3. define x and y location (predefine fishnet at 1km or 4km possibly, unique to each storm name.) uniformly or importance sampled with a likelihood ratio weight.
 4. evaluate storm type (should be in the storm name from the selected storm.)
-5. use f(st)=>date (should be a set of emperical distributions of date ranges per st)
-6. sample year uniformly (should be based on a start and end date of the por, making sure the date is contained.)
-7. sample calibration event id (should be 1-6 options.)
+5. sample calibration event id (should be 1-6 options.)
+6. use f(st)=>date (should be a set of emperical distributions of date ranges per st)
+7. sample year uniformly (should be based on a start and end date of the por, making sure the date is contained.) steps 5-7 draw from a generator derived from the event seed, shared with select_random_basin.
 8. get basin file name (should be `44*6*365` combinations.)
 9. store in tiledb database or dump to csv
 */
@@ -31,18 +36,106 @@ type FullSimulationSST struct {
 type FullSimulationResult []EventResult
 
 type EventResult struct {
-	EventNumber int64   `eventstore:"event_number"`
-	StormPath   string  `eventstore:"storm_path"`
-	X           float64 `eventstore:"x"`
-	Y           float64 `eventstore:"y"`
-	StormType   string  `eventstore:"storm_type"`
-	StormDate   string  `eventstore:"storm_date"`
-	BasinPath   string  `eventstore:"basin_path"`
+	EventNumber     int64   `eventstore:"event_number"`
+	StormPath       string  `eventstore:"storm_path"`
+	X               float64 `eventstore:"x"`
+	Y               float64 `eventstore:"y"`
+	StormType       string  `eventstore:"storm_type"`
+	StormDate       string  `eventstore:"storm_date"`
+	BasinPath       string  `eventstore:"basin_path"`
+	StormWeight     float64 `eventstore:"storm_weight" eventschema:"2"`
+	SamplingWeight  float64 `eventstore:"sampling_weight" eventschema:"2"` //likelihood ratio of the placement, one unless placements are importance sampled.
+	SimulationStart string  `eventstore:"simulation_start" eventschema:"2"`
+	SimulationEnd   string  `eventstore:"simulation_end" eventschema:"2"`
+	//optional columns, zero if unknown unless noted.
+	PlacementDensity   float64 `eventstore:"placement_density" eventschema:"3"` //probability of the placement under the density it was sampled from.
+	StormRank          int64   `eventstore:"storm_rank" eventschema:"3"`
+	StormDurationHours int64   `eventstore:"storm_duration_hours" eventschema:"3"`
+	DX                 float64 `eventstore:"dx" eventschema:"3"` //transposition offset from the storm center, NaN without storm centers.
+	DY                 float64 `eventstore:"dy" eventschema:"3"`
 }
 
+// simulationWindowFormat is the format of the simulation start and end of an event.
+const simulationWindowFormat string = "2006-01-02 15:04"
+
 func InitFullRealizationSST(a cc.Action) *FullSimulationSST {
 	return &FullSimulationSST{action: a}
 }
+
+// fullSimulationInputs are the catalog, placement, seasonality and antecedent condition inputs sampled for each event.
+type fullSimulationInputs struct {
+	stormNames            []string
+	stormWeights          utils.StormWeights
+	calibrationEventNames []string
+	basinRootDir          string
+	basinName             string
+	fishnets              utils.FishNetMap
+	fishnettypeorname     string
+	seasonalDistributions utils.StormTypeSeasonalityDistributionMap
+	porStart              time.Time
+	porEnd                time.Time
+	bootstrapCatalog      bool
+	bootstrapLength       int
+	controlWindow         bool
+	warmUp                time.Duration
+	recession             time.Duration
+	stormDuration         time.Duration //if zero the duration is parsed from the storm name.
+	timeInterval          int
+	pathPolicy            string
+	antecedentBasins      []utils.AntecedentBasin
+	availableBasins       map[string]bool
+	placementDensity      utils.PlacementDensity
+	placementDepths       map[string]map[utils.Coordinate]float64 //basin average depths by storm file name without extension.
+	stormCenters          utils.StormCenters
+	eventSchemaVersion    int
+	blockStormTypes       []utils.BlockStormTypeCount //if provided each event is sampled from the storms of its block storm type.
+}
+
+const (
+	NoPathValidation       string = "none"
+	FailPathValidation     string = "fail"
+	ResamplePathValidation string = "resample"
+	NearestPathValidation  string = "nearest"
+)
+
+// maxPathResamples limits the number of dates and calibration events drawn to replace a missing basin.
+const maxPathResamples int = 1000
+
+// pathCorrection records an event whose sampled basin did not exist.
+type pathCorrection struct {
+	EventNumber       int64
+	StormPath         string
+	OriginalBasinPath string
+	BasinPath         string
+	Policy            string
+}
+
+// pathValidationSummary counts the events checked and corrected when validating sampled paths.
+type pathValidationSummary struct {
+	Checked       int
+	MissingBasins int
+	Corrections   []pathCorrection
+}
+
+// realizationCatalog is the set of storms, placements and seasonality distributions events are sampled from within a realization.
+type realizationCatalog struct {
+	stormNames            []string
+	weighted              bool
+	stormDistribution     utils.WeightedIndexDistribution
+	fishnets              utils.FishNetMap
+	seasonalDistributions utils.StormTypeSeasonalityDistributionMap
+	//importance sampling distributions over each fishnet, built when first sampled.
+	placementDistributions map[string]utils.ImportanceDistribution
+	//the storms of each storm type (lower case), built when first sampled.
+	stormTypeStorms map[string]stormsOfType
+}
+
+// stormsOfType are the catalog indices of the storms of a storm type and the distribution they are sampled from.
+type stormsOfType struct {
+	indices      []int
+	distribution utils.WeightedIndexDistribution
+}
+
 func (frsst *FullSimulationSST) Compute(pm *cc.PluginManager) error {
 	a := frsst.action
 	//get parameters
@@ -52,94 +145,559 @@ func (frsst *FullSimulationSST) Compute(pm *cc.PluginManager) error {
 	if err != nil {
 		return err
 	}
+	inputs, err := readFullSimulationInputs(a)
+	if err != nil {
+		return err
+	}
+
+	seeds, err := utils.GetSeeds(a)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := utils.GetBlocks(pm, a)
+	if err != nil {
+		return err
+	}
+
+	results, summary, err := compute(inputs, seeds, blocks)
+	if err != nil {
+		return err
+	}
+	err = reportPathValidation(pm, a, inputs, summary)
+	if err != nil {
+		return err
+	}
+	//write results to data stores
+	if outputDataSource.StoreName == "store" {
+		return writeResultsToTileDB(pm, outputDataSource.StoreName, results, outputDataSource.Name, inputs.eventSchemaVersion) //update this to not referenceblock store, and also not hardcode the name to "storms"
+	} else {
+		return writeResultsToCSV(a.IOManager, outputDataSource, results, inputs.eventSchemaVersion)
+	}
+
+}
+func readFullSimulationInputs(a cc.Action) (fullSimulationInputs, error) {
+	var inputs fullSimulationInputs
 	///get storms
 	stormDirectory := a.Attributes.GetStringOrFail("storms_directory")
 	stormsStoreKey := a.Attributes.GetStringOrFail("storms_store") //expecting this to be an s3 bucket?
 	stormList, err := utils.ListAllPaths(a.IOManager, stormsStoreKey, stormDirectory, "*.dss")
 	if err != nil {
-		return err
+		return inputs, err
+	}
+	inputs.stormNames = stormList
+	//optional knowledge uncertainty, each realization bootstraps its own catalog, placements and seasonality.
+	inputs.bootstrapCatalog = a.Attributes.GetBooleanOrDefault("bootstrap_catalog", false)
+	inputs.bootstrapLength = a.Attributes.GetIntOrDefault("bootstrap_catalog_length", len(stormList))
+	if inputs.bootstrapCatalog && inputs.bootstrapLength < 1 {
+		return inputs, fmt.Errorf("bootstrap_catalog_length must be at least 1")
+	}
+	//optional storm type counts of each block from generate_blocks, if not provided only the total arrival rate of the blocks is used.
+	blockStormTypesFile := a.Attributes.GetStringOrDefault("block_storm_types_file", "")
+	if blockStormTypesFile != "" {
+		blockStormTypesStoreKey := a.Attributes.GetStringOrFail("block_storm_types_store")
+		inputs.blockStormTypes, err = utils.ReadBlockStormTypeCounts(a.IOManager, blockStormTypesStoreKey, blockStormTypesFile)
+		if err != nil {
+			return inputs, err
+		}
+	}
+	//optional storm weights, if not provided storms are sampled with uniform probability
+	stormWeightsFile := a.Attributes.GetStringOrDefault("storm_weights_file", "")
+	if stormWeightsFile != "" {
+		stormWeightsStoreKey := a.Attributes.GetStringOrDefault("storm_weights_store", stormsStoreKey)
+		inputs.stormWeights, err = utils.ReadStormWeights(a.IOManager, stormWeightsStoreKey, stormWeightsFile)
+		if err != nil {
+			return inputs, err
+		}
 	}
-	//if i wanted to bootstrap, i could bootstrap the storm list now...
 
 	///use fishnets to figure out placements - select from list of valid placements. fishnets are currently expected to be unique to each storm... could be converted to be unique to each storm type.
 	fishnetDirectory := a.Attributes.GetStringOrFail("fishnet_directory")
 	fishnetStoreKey := a.Attributes.GetStringOrFail("fishnet_store")
-	fishnettypeorname := a.Attributes.GetStringOrFail("fishnet_type_or_name")
+	inputs.fishnettypeorname = a.Attributes.GetStringOrFail("fishnet_type_or_name")
 	fishnetList, err := utils.ListAllPaths(a.IOManager, fishnetStoreKey, fishnetDirectory, "*.csv")
 	if err != nil {
-		return err
+		return inputs, err
 	}
-	fishNetMap, err := utils.ReadFishNets(a.IOManager, fishnetStoreKey, fishnetList, fishnetDirectory)
+	inputs.fishnets, err = utils.ReadFishNets(a.IOManager, fishnetStoreKey, fishnetList, fishnetDirectory)
 	if err != nil {
-		return err
+		return inputs, err
+	}
+	//optional storm centers for the transposition offsets.
+	stormCentersFile := a.Attributes.GetStringOrDefault("storm_centers_file", "")
+	if stormCentersFile != "" {
+		stormCentersStoreKey := a.Attributes.GetStringOrDefault("storm_centers_store", stormsStoreKey)
+		inputs.stormCenters, err = utils.ReadStormCenters(a.IOManager, stormCentersStoreKey, stormCentersFile)
+		if err != nil {
+			return inputs, err
+		}
+	}
+	//the newer columns are opt in, an existing output array was created with the version 1 columns.
+	inputs.eventSchemaVersion = a.Attributes.GetIntOrDefault("event_schema_version", EventSchemaV1)
+	if _, err = eventColumns(inputs.eventSchemaVersion); err != nil {
+		return inputs, err
+	}
+	//optional importance sampling of placements.
+	inputs.placementDensity, err = PlacementDensityFromAttributes(a.Attributes)
+	if err != nil {
+		return inputs, err
+	}
+	if inputs.placementDensity.Method == utils.DepthPlacementSampling {
+		depthDirectory := a.Attributes.GetStringOrFail("placement_depth_directory")
+		depthStoreKey := a.Attributes.GetStringOrDefault("placement_depth_store", fishnetStoreKey)
+		depthList, err := utils.ListAllPaths(a.IOManager, depthStoreKey, depthDirectory, "*.csv")
+		if err != nil {
+			return inputs, err
+		}
+		inputs.placementDepths, err = utils.ReadBasinAverageDepths(a.IOManager, depthStoreKey, depthList, depthDirectory)
+		if err != nil {
+			return inputs, err
+		}
 	}
 	//storm type seasonality distributions
 	stormTypeSeasonalityDistributionDirectory := a.Attributes.GetStringOrFail("storm_type_seasonality_distribution_directory")
 	stormTypeSeasonalityDistributionStoreKey := a.Attributes.GetStringOrFail("storm_type_seasonality_distribution_store")
 	stormTypeDistributionList, err := utils.ListAllPaths(a.IOManager, stormTypeSeasonalityDistributionStoreKey, stormTypeSeasonalityDistributionDirectory, "*.csv")
 	if err != nil {
-		return err
+		return inputs, err
 	}
-	stormTypeSeasonalityDistributionsMap, err := utils.ReadStormDistributions(a.IOManager, stormTypeSeasonalityDistributionStoreKey, stormTypeDistributionList, stormTypeSeasonalityDistributionDirectory)
+	inputs.seasonalDistributions, err = utils.ReadStormDistributions(a.IOManager, stormTypeSeasonalityDistributionStoreKey, stormTypeDistributionList, stormTypeSeasonalityDistributionDirectory)
 	if err != nil {
-		return err
+		return inputs, err
 	}
 	//basin root directory
-	basinRootDir := a.Attributes.GetStringOrFail("basin_root_directory")
-	basinName := a.Attributes.GetStringOrFail("basin_name")
+	inputs.basinRootDir = a.Attributes.GetStringOrFail("basin_root_directory")
+	inputs.basinName = a.Attributes.GetStringOrFail("basin_name")
 	//time range of POR
 	porStartDateString := a.Attributes.GetStringOrFail("por_start_date")
-	porStartDate, err := time.Parse("20060102", porStartDateString)
+	inputs.porStart, err = time.Parse("20060102", porStartDateString)
 	if err != nil {
-		return err
+		return inputs, err
 	}
 	porEndDateString := a.Attributes.GetStringOrFail("por_end_date")
-	porEndDate, err := time.Parse("20060102", porEndDateString)
+	inputs.porEnd, err = time.Parse("20060102", porEndDateString)
 	if err != nil {
-		return err
+		return inputs, err
 	}
 	//calibration event strings
-	calibrationEvents, err := a.Attributes.GetStringSlice("calibration_event_names")
+	inputs.calibrationEventNames, err = a.Attributes.GetStringSlice("calibration_event_names")
 	if err != nil {
-		return err
+		return inputs, err
+	}
+	//optional existence validation of sampled basin paths, storms are sampled from the listing of the storms directory so they exist.
+	inputs.pathPolicy = a.Attributes.GetStringOrDefault("path_validation", NoPathValidation)
+	switch inputs.pathPolicy {
+	case NoPathValidation:
+	case FailPathValidation, ResamplePathValidation, NearestPathValidation:
+		basinStoreKey := a.Attributes.GetStringOrFail("basin_store")
+		basinDirectory := a.Attributes.GetStringOrDefault("basin_directory", inputs.basinRootDir)
+		basinExtension := a.Attributes.GetStringOrDefault("basin_extension", "basin")
+		basinList, err := utils.ListAllPaths(a.IOManager, basinStoreKey, basinDirectory, fmt.Sprintf("*.%v", basinExtension))
+		if err != nil {
+			return inputs, err
+		}
+		inputs.antecedentBasins = utils.ParseAntecedentBasins(basinList, inputs.basinName)
+		inputs.availableBasins = make(map[string]bool, len(inputs.antecedentBasins))
+		for _, b := range inputs.antecedentBasins {
+			inputs.availableBasins[b.Name()] = true
+		}
+	default:
+		return inputs, fmt.Errorf("unsupported path_validation %v, expected %v, %v, %v, or %v", inputs.pathPolicy, NoPathValidation, FailPathValidation, ResamplePathValidation, NearestPathValidation)
+	}
+	//optional simulation window around the storm date.
+	if _, ok := a.Attributes["control_warm_up_hours"]; ok {
+		inputs.controlWindow = true
+		inputs.warmUp = time.Duration(a.Attributes.GetIntOrFail("control_warm_up_hours")) * time.Hour
+		inputs.recession = time.Duration(a.Attributes.GetIntOrDefault("control_recession_hours", 0)) * time.Hour
+		inputs.stormDuration = time.Duration(a.Attributes.GetIntOrDefault("control_storm_duration_hours", 0)) * time.Hour
+		inputs.timeInterval = a.Attributes.GetIntOrDefault("control_time_interval", hms.DefaultTimeInterval)
 	}
+	//weighted events are biased without their weight columns, and the simulation window would not be written.
+	if inputs.eventSchemaVersion < EventSchemaV2 && (inputs.stormWeights != nil || inputs.placementDensity.Method != utils.UniformPlacementSampling || inputs.controlWindow) {
+		return inputs, fmt.Errorf("storm weights, placement_sampling and control_warm_up_hours write the event_schema_version %v columns, set event_schema_version to %v or later", EventSchemaV2, EventSchemaV2)
+	}
+	return inputs, nil
+}
 
-	seeds, err := utils.GetSeeds(a)
+// catalog creates the realization catalog from the full set of inputs.
+func (inputs fullSimulationInputs) catalog(stormNames []string) (realizationCatalog, error) {
+	catalog := realizationCatalog{
+		stormNames:             stormNames,
+		fishnets:               inputs.fishnets,
+		seasonalDistributions:  inputs.seasonalDistributions,
+		placementDistributions: make(map[string]utils.ImportanceDistribution),
+		stormTypeStorms:        make(map[string]stormsOfType),
+	}
+	if inputs.stormWeights != nil {
+		dist, err := inputs.stormWeights.Distribution(stormNames)
+		if err != nil {
+			return catalog, err
+		}
+		catalog.weighted = true
+		catalog.stormDistribution = dist
+	}
+	return catalog, nil
+}
+
+// bootstrapRealizationCatalog samples the storm catalog with replacement, resamples every fishnet with replacement, and resamples each
+// storm type seasonality distribution with a sample size equal to the number of storms of that type in the original catalog.
+func (inputs fullSimulationInputs) bootstrapRealizationCatalog(realizationSeed int64) (realizationCatalog, error) {
+	kurng := rand.New(rand.NewSource(realizationSeed))
+	catalogRng := rand.New(rand.NewSource(kurng.Int63()))
+	seasonalityRng := rand.New(rand.NewSource(kurng.Int63()))
+	placementRng := rand.New(rand.NewSource(kurng.Int63()))
+	stormNames := make([]string, inputs.bootstrapLength)
+	for i := range stormNames {
+		stormNames[i] = inputs.stormNames[catalogRng.Intn(len(inputs.stormNames))] //sample with replacement.
+	}
+	catalog, err := inputs.catalog(stormNames)
 	if err != nil {
-		return err
+		return catalog, err
+	}
+	//sort keys so the resampling order is reproducible.
+	fishnetNames := make([]string, 0, len(inputs.fishnets))
+	for name := range inputs.fishnets {
+		fishnetNames = append(fishnetNames, name)
+	}
+	sort.Strings(fishnetNames)
+	catalog.fishnets = make(utils.FishNetMap, len(inputs.fishnets))
+	for _, name := range fishnetNames {
+		catalog.fishnets[name] = inputs.fishnets[name].Bootstrap(placementRng)
 	}
+	typeCounts := make(map[string]int)
+	for _, name := range inputs.stormNames {
+		typeCounts[stormTypeFromName(name)]++
+	}
+	stormTypes := make([]string, 0, len(inputs.seasonalDistributions))
+	for st := range inputs.seasonalDistributions {
+		stormTypes = append(stormTypes, st)
+	}
+	sort.Strings(stormTypes)
+	catalog.seasonalDistributions = make(utils.StormTypeSeasonalityDistributionMap, len(inputs.seasonalDistributions))
+	for _, st := range stormTypes {
+		if typeCounts[st] > 0 {
+			catalog.seasonalDistributions[st] = inputs.seasonalDistributions[st].Bootstrap(seasonalityRng, typeCounts[st])
+		} else {
+			catalog.seasonalDistributions[st] = inputs.seasonalDistributions[st]
+		}
+	}
+	return catalog, nil
+}
 
-	blocks, err := utils.GetBlocks(pm, a)
+// sampleStormOfType samples a storm of the storm type (matched case insensitively) uniformly, or by its storm weight renormalized over the
+// storms of the type. The catalog index and the probability of the storm given its type are returned.
+func (catalog realizationCatalog) sampleStormOfType(inputs fullSimulationInputs, enRng *rand.Rand, stormType string) (int, float64, error) {
+	key := strings.ToLower(stormType)
+	storms, ok := catalog.stormTypeStorms[key]
+	if !ok {
+		names := make([]string, 0)
+		for i, name := range catalog.stormNames {
+			if strings.ToLower(stormTypeFromName(name)) == key {
+				storms.indices = append(storms.indices, i)
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return 0, 0, fmt.Errorf("the catalog does not have a storm of type %v", stormType)
+		}
+		weights := make([]float64, len(names))
+		for i := range weights {
+			weights[i] = 1.0 / float64(len(names))
+		}
+		if inputs.stormWeights != nil {
+			var err error
+			weights, err = inputs.stormWeights.Normalize(names)
+			if err != nil {
+				return 0, 0, fmt.Errorf("could not weight the storms of type %v: %v", stormType, err)
+			}
+		}
+		storms.distribution = utils.NewWeightedIndexDistribution(weights)
+		catalog.stormTypeStorms[key] = storms
+	}
+	i := storms.distribution.Sample(enRng.Float64())
+	return storms.indices[i], storms.distribution.Weight(i), nil
+}
+
+// samplePlacement samples a location from the fishnet and returns its probability and its likelihood ratio to uniform sampling of the fishnet.
+// depth sampling builds a distribution for each storm and fishnet, truncated normal sampling one for each fishnet.
+func (catalog realizationCatalog) samplePlacement(inputs fullSimulationInputs, enRng *rand.Rand, fishnetName string, stormName string, fishnet utils.CoordinateList) (utils.Coordinate, float64, float64, error) {
+	density := inputs.placementDensity
+	if density.Method == utils.UniformPlacementSampling {
+		return fishnet.Coordinates[enRng.Intn(len(fishnet.Coordinates))], 1 / float64(len(fishnet.Coordinates)), 1, nil
+	}
+	stormFileName := strings.TrimSuffix(path.Base(stormName), path.Ext(stormName))
+	key := fishnetName
+	if density.Method == utils.DepthPlacementSampling {
+		key = fmt.Sprintf("%v/%v", fishnetName, stormFileName)
+	}
+	dist, ok := catalog.placementDistributions[key]
+	if !ok {
+		var scores []float64
+		if density.Method == utils.DepthPlacementSampling {
+			depths, ok := inputs.placementDepths[stormFileName]
+			if !ok {
+				return utils.Coordinate{}, 0, 0, fmt.Errorf("could not find basin average depths for storm %v", stormFileName)
+			}
+			scores = utils.DepthScores(fishnet.Coordinates, depths)
+		} else {
+			scores = utils.TruncatedNormalScores(fishnet.Coordinates, density.Center, density.StandardDeviation)
+		}
+		var err error
+		dist, err = utils.NewImportanceDistribution(scores, density.UniformFraction)
+		if err != nil {
+			return utils.Coordinate{}, 0, 0, fmt.Errorf("could not build the placement density for %v: %v", key, err)
+		}
+		catalog.placementDistributions[key] = dist
+	}
+	index, ratio := dist.Sample(enRng.Float64())
+	return fishnet.Coordinates[index], dist.Probability(index), ratio, nil
+}
+
+// stormRankFromName parses the storm rank from a storm name following yyyymmdd_xxhr_storm-type_storm-rank, the rank may have a letter prefix (r01).
+func stormRankFromName(stormName string) (int64, error) {
+	parts := strings.Split(strings.TrimSuffix(stormName, path.Ext(stormName)), "_")
+	if len(parts) < 4 {
+		return 0, fmt.Errorf("could not parse a storm rank from %v", stormName)
+	}
+	rank, err := strconv.ParseInt(strings.TrimLeft(parts[3], "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"), 10, 64)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("could not parse a storm rank from %v", stormName)
 	}
+	return rank, nil
+}
 
-	results, err := compute(stormList, calibrationEvents, basinRootDir, basinName, fishNetMap, fishnettypeorname, stormTypeSeasonalityDistributionsMap, porStartDate, porEndDate, seeds, blocks)
+// stormDurationFromName parses the storm duration from a storm name following yyyymmdd_xxhr_storm-type_storm-rank.
+func stormDurationFromName(stormName string) (time.Duration, error) {
+	parts := strings.Split(stormName, "_")
+	if len(parts) < 2 || !strings.HasSuffix(parts[1], "hr") {
+		return 0, fmt.Errorf("could not parse a storm duration from %v", stormName)
+	}
+	hours, err := strconv.Atoi(strings.TrimSuffix(parts[1], "hr"))
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("could not parse a storm duration from %v", stormName)
 	}
-	//write results to data stores
-	if outputDataSource.StoreName == "store" {
-		return writeResultsToTileDB(pm, outputDataSource.StoreName, results, outputDataSource.Name) //update this to not referenceblock store, and also not hardcode the name to "storms"
-	} else {
-		return writeResultsToCSV(a.IOManager, outputDataSource, results)
+	return time.Duration(hours) * time.Hour, nil
+}
+
+// simulationWindow builds the control window around the storm date.
+func (inputs fullSimulationInputs) simulationWindow(eventNumber int64, stormName string, stormDate time.Time) (time.Time, time.Time, error) {
+	stormDuration := inputs.stormDuration
+	if stormDuration == 0 {
+		var err error
+		stormDuration, err = stormDurationFromName(path.Base(stormName))
+		if err != nil {
+			return stormDate, stormDate, err
+		}
+	}
+	control := hms.Control{Name: fmt.Sprint(eventNumber), TimeInterval: inputs.timeInterval}
+	err := control.WindowAroundStorm(stormDate, inputs.warmUp, stormDuration, inputs.recession)
+	if err != nil {
+		return stormDate, stormDate, err
+	}
+	start, err := control.StartDateAndTime()
+	if err != nil {
+		return start, start, err
+	}
+	end, err := control.EndDateAndTime()
+	return start, end, err
+}
+
+// stormTypeFromName parses the storm type from a storm name.
+func stormTypeFromName(stormName string) string {
+	return strings.Split(stormName, "_")[2] //assuming yyyymmdd_xxhr_data-type_storm-type_storm-rank - if data-type is dropped as i hope this needs to be updated to 2
+}
+
+// validatePaths checks the sampled basin exists, a missing basin is handled by the path policy: fail, resample the date and calibration event, or use the basin with the nearest date for the calibration event.
+func (inputs fullSimulationInputs) validatePaths(eventNumber int64, stormName string, stormType string, basin utils.AntecedentBasin, rng *rand.Rand, sampler antecedentSampler, summary *pathValidationSummary) (time.Time, utils.AntecedentBasin, error) {
+	summary.Checked++
+	if inputs.availableBasins[basin.Name()] {
+		return basin.Date, basin, nil
+	}
+	summary.MissingBasins++
+	correction := pathCorrection{
+		EventNumber:       eventNumber,
+		StormPath:         stormName,
+		OriginalBasinPath: basin.Path(inputs.basinRootDir),
+		Policy:            inputs.pathPolicy,
+	}
+	switch inputs.pathPolicy {
+	case ResamplePathValidation:
+		for i := 0; i < maxPathResamples; i++ {
+			candidate, err := sampler.sample(rng, stormType)
+			if err != nil {
+				return basin.Date, basin, err
+			}
+			if inputs.availableBasins[candidate.Name()] {
+				correction.BasinPath = candidate.Path(inputs.basinRootDir)
+				summary.Corrections = append(summary.Corrections, correction)
+				return candidate.Date, candidate, nil
+			}
+		}
+		return basin.Date, basin, fmt.Errorf("event %v could not find an existing basin after %v resamples", eventNumber, maxPathResamples)
+	case NearestPathValidation:
+		nearest, err := utils.NearestAntecedentBasin(inputs.antecedentBasins, basin.Date, basin.CalibrationEvent, false)
+		if err != nil {
+			return basin.Date, basin, fmt.Errorf("event %v basin %v does not exist: %v", eventNumber, correction.OriginalBasinPath, err)
+		}
+		correction.BasinPath = nearest.Path(inputs.basinRootDir)
+		summary.Corrections = append(summary.Corrections, correction)
+		return basin.Date, nearest, nil
+	default:
+		return basin.Date, basin, fmt.Errorf("event %v basin %v does not exist", eventNumber, correction.OriginalBasinPath)
+	}
+}
+
+// reportPathValidation logs the number of events checked and corrected, and writes the corrections if a summary file is provided.
+func reportPathValidation(pm *cc.PluginManager, a cc.Action, inputs fullSimulationInputs, summary pathValidationSummary) error {
+	if inputs.pathPolicy == NoPathValidation {
+		return nil
+	}
+	for _, c := range summary.Corrections {
+		pm.Logger.Info(fmt.Sprintf("event %v basin %v does not exist, using %v (%v)", c.EventNumber, c.OriginalBasinPath, c.BasinPath, c.Policy))
+	}
+	pm.Logger.Info(fmt.Sprintf("validated paths for %v events, %v sampled basins did not exist and %v events were corrected", summary.Checked, summary.MissingBasins, len(summary.Corrections)))
+	summaryFile := a.Attributes.GetStringOrDefault("path_validation_summary_file", "")
+	if summaryFile == "" {
+		return nil
 	}
+	summaryStore := a.Attributes.GetStringOrDefault("path_validation_summary_store", a.Attributes.GetStringOrFail("basin_store"))
+	return putStoreFile(pm, summaryStore, summaryFile, summary.ToBytes())
+}
+
+// ToBytes writes the corrections as a csv.
+func (pvs pathValidationSummary) ToBytes() []byte {
+	data := "event_number,storm_path,original_basin_path,basin_path,policy"
+	for _, c := range pvs.Corrections {
+		data = fmt.Sprintf("%v\n%v,%v,%v,%v,%v", data, c.EventNumber, c.StormPath, c.OriginalBasinPath, c.BasinPath, c.Policy)
+	}
+	return []byte(data)
+}
+
+// antecedentSampler samples the antecedent condition basin of an event, full_simulation_sst and select_random_basin share it so both
+// select the same basin for the same event seed and storm type.
+type antecedentSampler struct {
+	seasonalDistributions utils.StormTypeSeasonalityDistributionMap
+	porStart              time.Time
+	porEnd                time.Time
+	basinName             string
+	calibrationEventNames []string
+}
 
+// antecedentRng derives the generator an antecedent condition basin is sampled with from the event seed, the basin does not depend on
+// the storm and placement draws of the event.
+func antecedentRng(eventSeed int64) *rand.Rand {
+	return rand.New(rand.NewSource(rand.New(rand.NewSource(eventSeed)).Int63()))
 }
-func compute(stormNames []string, calibrationEventNames []string, basinRootDir string, basinName string, fishnets utils.FishNetMap, fishnettypeorname string, seasonalDistributions utils.StormTypeSeasonalityDistributionMap, porStart time.Time, porEnd time.Time, seeds []utils.SeedSet, blocks []utils.Block) (FullSimulationResult, error) {
+
+// sample samples a calibration event, then a date from the seasonality distribution of the storm type and a year in the por.
+func (as antecedentSampler) sample(rng *rand.Rand, stormType string) (utils.AntecedentBasin, error) {
+	calibrationEvent := utils.SampleCalibrationEvent(rng, as.calibrationEventNames)
+	seasonalDistribution, ok := as.seasonalDistributions[stormType]
+	if !ok {
+		return utils.AntecedentBasin{}, fmt.Errorf("could not find the seasonal distribution for type %v", stormType)
+	}
+	date, err := sampleStormDate(rng, seasonalDistribution, as.porStart, as.porEnd)
+	if err != nil {
+		return utils.AntecedentBasin{}, err
+	}
+	return utils.AntecedentBasin{Date: date, BasinName: as.basinName, CalibrationEvent: calibrationEvent}, nil
+}
+
+// sampleStormDate samples a day of year from the seasonal distribution and a year in the por that contains that day.
+func sampleStormDate(enRng *rand.Rand, seasonalDistribution utils.DiscreteEmpiricalDistribution, porStart time.Time, porEnd time.Time) (time.Time, error) {
+	//fetch day of year
+	dayOfYear := seasonalDistribution.Sample(enRng.Float64())
+	//determine year.
+	yearCount := porEnd.Year() - porStart.Year() //this needs to be checked on both ends for valid dates.
+	dayofyearInrange := false
+	year := 0
+	for !dayofyearInrange {
+		initalYearGuess := enRng.Intn(yearCount+1) + porStart.Year() //+1 is due to [0,n)
+		if initalYearGuess == porStart.Year() {
+			if dayOfYear >= porStart.YearDay() {
+				dayofyearInrange = true
+				year = initalYearGuess
+			}
+		} else if initalYearGuess == porEnd.Year() {
+			if dayOfYear <= porEnd.YearDay() {
+				dayofyearInrange = true
+				year = initalYearGuess
+			}
+		} else if porStart.Year() < initalYearGuess && initalYearGuess < porEnd.Year() {
+			dayofyearInrange = true
+			year = initalYearGuess
+		}
+	}
+	//create start date from day of year and year
+	startDate := time.Date(year, 1, 1, 1, 1, 1, 1, time.Local)
+	//convert day of year to duration
+	sdur := fmt.Sprintf("%vh", (dayOfYear-1)*24)
+	dur, err := time.ParseDuration(sdur)
+	if err != nil {
+		return startDate, err
+	}
+	return startDate.Add(dur), nil
+}
+func compute(inputs fullSimulationInputs, seeds []utils.SeedSet, blocks []utils.Block) (FullSimulationResult, pathValidationSummary, error) {
 	results := make(FullSimulationResult, 0)
+	var summary pathValidationSummary
+	fishnettypeorname := inputs.fishnettypeorname
+	catalog, err := inputs.catalog(inputs.stormNames)
+	if err != nil {
+		return results, summary, err
+	}
+	var eventStormTypes map[int64]string
+	if inputs.blockStormTypes != nil {
+		eventStormTypes, err = utils.EventStormTypes(blocks, inputs.blockStormTypes)
+		if err != nil {
+			return results, summary, err
+		}
+	}
+	bootstrappedCatalogs := make(map[int32]realizationCatalog)
 	for _, b := range blocks {
 		if b.BlockEventCount > 0 {
+			if inputs.bootstrapCatalog && int(b.BlockEventStart) <= len(seeds) {
+				//every event in a realization shares the realization seed.
+				bootstrapped, ok := bootstrappedCatalogs[b.RealizationIndex]
+				if !ok {
+					bootstrapped, err = inputs.bootstrapRealizationCatalog(seeds[b.BlockEventStart-1].RealizationSeed)
+					if err != nil {
+						return results, summary, err
+					}
+					bootstrappedCatalogs[b.RealizationIndex] = bootstrapped
+				}
+				catalog = bootstrapped
+			}
+			stormNames := catalog.stormNames
+			fishnets := catalog.fishnets
+			sampler := antecedentSampler{
+				seasonalDistributions: catalog.seasonalDistributions,
+				porStart:              inputs.porStart,
+				porEnd:                inputs.porEnd,
+				basinName:             inputs.basinName,
+				calibrationEventNames: inputs.calibrationEventNames,
+			}
 			for en := b.BlockEventStart; en <= b.BlockEventEnd; en++ {
 				//create random number generator for event
 				if int(en) <= len(seeds) {
 					enRng := rand.New(rand.NewSource(seeds[en-1].EventSeed))
 					//sample storm name
-					stormName := stormNames[enRng.Intn(len(stormNames))]
+					var stormIndex int
+					stormWeight := 1.0 / float64(len(stormNames))
+					if arrivalType, ok := eventStormTypes[en]; ok {
+						stormIndex, stormWeight, err = catalog.sampleStormOfType(inputs, enRng, arrivalType)
+						if err != nil {
+							return results, summary, fmt.Errorf("event %v: %v", en, err)
+						}
+					} else if catalog.weighted {
+						stormIndex = catalog.stormDistribution.Sample(enRng.Float64())
+						stormWeight = catalog.stormDistribution.Weight(stormIndex)
+					} else {
+						stormIndex = enRng.Intn(len(stormNames))
+					}
+					stormName := stormNames[stormIndex]
 					//calculate storm type from storm name
-					stormType := strings.Split(stormName, "_")[2] //assuming yyyymmdd_xxhr_data-type_storm-type_storm-rank - if data-type is dropped as i hope this needs to be updated to 2
-					//sample calibration event
-					calibrationEvent := calibrationEventNames[enRng.Intn(len(calibrationEventNames))]
+					stormType := stormTypeFromName(stormName)
 					//fetch fishnet based on storm name -
 					sname := strings.Split(stormName, ".")[0]
 					sname = strings.Replace(sname, "st", "ST", -1) //how did this happen?//storm name just file name no extension.
@@ -151,55 +709,59 @@ func compute(stormNames []string, calibrationEventNames []string, basinRootDir s
 					fishnet, ok := fishnets[sname]
 					if !ok {
 
-						return results, fmt.Errorf("could not find fishnet %v in fishnet map", sname)
+						return results, summary, fmt.Errorf("could not find fishnet %v in fishnet map", sname)
 					}
 					//sample location
-					coordinate := fishnet.Coordinates[enRng.Intn(len(fishnet.Coordinates))]
-					//fetch seasonal distribution based on storm type
-					seasonalDistribution, ok := seasonalDistributions[stormType]
-					if !ok {
-						return results, fmt.Errorf("could not find the seasonal distribution for type %v", stormType)
-					}
-					//fetch day of year
-					dayOfYear := seasonalDistribution.Sample(enRng.Float64())
-					//determine year.
-					yearCount := porEnd.Year() - porStart.Year() //this needs to be checked on both ends for valid dates.
-					dayofyearInrange := false
-					year := 0
-					for !dayofyearInrange {
-						initalYearGuess := enRng.Intn(yearCount+1) + porStart.Year() //+1 is due to [0,n)
-						if initalYearGuess == porStart.Year() {
-							if dayOfYear >= porStart.YearDay() {
-								dayofyearInrange = true
-								year = initalYearGuess
-							}
-						} else if initalYearGuess == porEnd.Year() {
-							if dayOfYear <= porEnd.YearDay() {
-								dayofyearInrange = true
-								year = initalYearGuess
-							}
-						} else if porStart.Year() < initalYearGuess && initalYearGuess < porEnd.Year() {
-							dayofyearInrange = true
-							year = initalYearGuess
-						}
+					coordinate, placementDensity, samplingWeight, err := catalog.samplePlacement(inputs, enRng, sname, stormName, fishnet)
+					if err != nil {
+						return results, summary, err
 					}
-					//create start date from day of year and year
-					startDate := time.Date(year, 1, 1, 1, 1, 1, 1, time.Local)
-					//convert day of year to duration
-					sdur := fmt.Sprintf("%vh", (dayOfYear-1)*24)
-					dur, err := time.ParseDuration(sdur)
+					//sample the calibration event and storm date of the antecedent condition basin.
+					antRng := antecedentRng(seeds[en-1].EventSeed)
+					basin, err := sampler.sample(antRng, stormType)
 					if err != nil {
-						return results, err
+						return results, summary, err
+					}
+					startDate := basin.Date
+					if inputs.pathPolicy != NoPathValidation {
+						startDate, basin, err = inputs.validatePaths(en, stormName, stormType, basin, antRng, sampler, &summary)
+						if err != nil {
+							return results, summary, err
+						}
 					}
-					startDate = startDate.Add(dur)
 					event := EventResult{
-						EventNumber: en,
-						StormPath:   stormName,
-						StormType:   stormType,
-						X:           coordinate.X,
-						Y:           coordinate.Y,
-						StormDate:   startDate.Format("20060102"),
-						BasinPath:   fmt.Sprintf("%v/%v_%v_%v", basinRootDir, startDate.Format("2006-01-02"), basinName, calibrationEvent),
+						EventNumber:      en,
+						StormPath:        stormName,
+						StormType:        stormType,
+						X:                coordinate.X,
+						Y:                coordinate.Y,
+						StormDate:        startDate.Format("20060102"),
+						BasinPath:        basin.Path(inputs.basinRootDir),
+						StormWeight:      stormWeight,
+						SamplingWeight:   samplingWeight,
+						PlacementDensity: placementDensity,
+						DX:               math.NaN(),
+						DY:               math.NaN(),
+					}
+					//the optional columns are left at zero (NaN for the offsets) if they cannot be determined.
+					event.StormRank, _ = stormRankFromName(path.Base(stormName))
+					event.StormDurationHours = int64(inputs.stormDuration / time.Hour)
+					if event.StormDurationHours == 0 {
+						duration, _ := stormDurationFromName(path.Base(stormName))
+						event.StormDurationHours = int64(duration / time.Hour)
+					}
+					if center, ok := inputs.stormCenters.Center(path.Base(stormName)); ok {
+						event.DX = coordinate.X - center.X
+						event.DY = coordinate.Y - center.Y
+					}
+					if inputs.controlWindow {
+						stormDay := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+						start, end, err := inputs.simulationWindow(en, stormName, stormDay)
+						if err != nil {
+							return results, summary, err
+						}
+						event.SimulationStart = start.Format(simulationWindowFormat)
+						event.SimulationEnd = end.Format(simulationWindowFormat)
 					}
 					results = append(results, event)
 				}
@@ -207,28 +769,55 @@ func compute(stormNames []string, calibrationEventNames []string, basinRootDir s
 
 		}
 	}
-	return results, nil
+	return results, summary, nil
 }
-func writeResultsToTileDB(pm *cc.PluginManager, storeKey string, results FullSimulationResult, tableName string) error {
-	recordset, err := cc.NewEventStoreRecordset(pm, &results, storeKey, tableName)
+
+// writeResultsToTileDB writes the columns of the schema version as a recordset, the schema version and column names are stored in the
+// <tableName>_schema_version and <tableName>_columns metadata.
+func writeResultsToTileDB(pm *cc.PluginManager, storeKey string, results FullSimulationResult, tableName string, schemaVersion int) error {
+	columns, err := eventColumns(schemaVersion)
+	if err != nil {
+		return err
+	}
+	attributes, err := cc.StructSliceToArrayConfig(&results)
+	if err != nil {
+		return err
+	}
+	attributes = schemaArrayAttributes(attributes, columns)
+	store, err := pm.GetStore(storeKey)
 	if err != nil {
 		return err
 	}
-	err = recordset.Create()
+	mds, ok := store.Session.(cc.MultiDimensionalArrayStore)
+	if !ok {
+		return fmt.Errorf("the store named %v does not implement multidimensional array store", storeKey)
+	}
+	input, err := attributes.BuildCreateArrayInput(tableName)
 	if err != nil {
 		return err
 	}
-	return recordset.Write(&results)
+	err = mds.CreateArray(input)
+	if err != nil {
+		return err
+	}
+	err = mds.PutArray(attributes.BuildPutArrayInput(tableName, cc.ARRAY_DENSE))
+	if err != nil {
+		return err
+	}
+	err = mds.PutMetadata(fmt.Sprintf("%v_schema_version", tableName), int64(schemaVersion))
+	if err != nil {
+		return err
+	}
+	return mds.PutMetadata(fmt.Sprintf("%v_columns", tableName), eventColumnNames(columns))
 }
-func writeResultsToCSV(iomanager cc.IOManager, ds cc.DataSource, results FullSimulationResult) error {
-	//create a header
-	data := "event_number,storm_path,x,y,storm_type,storm_date,basin_path"
-	for _, r := range results {
-		data = fmt.Sprintf("%v\n%v,%v,%v,%v,%v,%v,%v", data, r.EventNumber, r.StormPath, r.X, r.Y, r.StormType, r.StormDate, r.BasinPath)
+func writeResultsToCSV(iomanager cc.IOManager, ds cc.DataSource, results FullSimulationResult, schemaVersion int) error {
+	columns, err := eventColumns(schemaVersion)
+	if err != nil {
+		return err
 	}
-	bytedata := []byte(data)
+	bytedata := results.toCSV(columns)
 	writer := bytes.NewReader(bytedata)
-	_, err := iomanager.Put(cc.PutOpInput{
+	_, err = iomanager.Put(cc.PutOpInput{
 		SrcReader:         writer,
 		DataSourceOpInput: cc.DataSourceOpInput{DataSourceName: ds.Name, PathKey: "default"},
 	})
@@ -2,6 +2,7 @@ package actions
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -54,9 +55,48 @@ func Test_RecordSet(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	err = writeResultsToTileDB(pm, "store", records, "storms")
+	err = writeResultsToTileDB(pm, "store", records, "storms", EventSchemaV1)
 	if err != nil {
 		fmt.Println(err)
 		t.Fail()
 	}
 }
+
+func TestEventColumns(t *testing.T) {
+	v1, err := eventColumns(EventSchemaV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(eventColumnNames(v1), ",") != "event_number,storm_path,x,y,storm_type,storm_date,basin_path" {
+		t.Errorf("unexpected version 1 columns %v", eventColumnNames(v1))
+	}
+	latest, err := eventColumns(LatestEventSchemaVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range v1 {
+		if latest[i] != c {
+			t.Errorf("expected column %v to keep its position in the latest schema", c.name)
+		}
+	}
+	results := FullSimulationResult{{EventNumber: 1, StormPath: "s.dss", X: 1.5, Y: 2, StormType: "st1", StormDate: "19790205", BasinPath: "b", StormWeight: .5, SamplingWeight: 1, DX: math.NaN(), DY: 3, StormRank: 2}}
+	lines := strings.Split(string(results.toCSV(latest)), "\n")
+	if lines[0] != "event_number,storm_path,x,y,storm_type,storm_date,basin_path,storm_weight,sampling_weight,simulation_start,simulation_end,placement_density,storm_rank,storm_duration_hours,dx,dy" {
+		t.Errorf("unexpected header %v", lines[0])
+	}
+	if lines[1] != "1,s.dss,1.5,2,st1,19790205,b,0.5,1,,,0,2,0,NaN,3" {
+		t.Errorf("unexpected row %v", lines[1])
+	}
+	if _, err = eventColumns(LatestEventSchemaVersion + 1); err == nil {
+		t.Error("expected an error for an unsupported schema version")
+	}
+}
+func TestStormRankFromName(t *testing.T) {
+	rank, err := stormRankFromName("19790205_72hr_st1_r12.dss")
+	if err != nil || rank != 12 {
+		t.Errorf("expected rank 12 got %v %v", rank, err)
+	}
+	if _, err = stormRankFromName("19790205_72hr_st1.dss"); err == nil {
+		t.Error("expected an error for a storm name without a rank")
+	}
+}
@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+/*
+This action generates the block structure (realization, block, event count, event start, event end) used by full_simulation_sst.
+The event count for each block is sampled from an annual arrival rate model by storm type (poisson or negative binomial),
+the block event count is the sum of the storm type counts. The storm type counts of each block can be written with the blocks so
+full_simulation_sst samples each event from the storms of its storm type. Each realization is seeded from the block seed of the
+first event row of the realization in the seed table.
+*/
+type GenerateBlocksAction struct {
+	action cc.Action
+}
+
+func InitGenerateBlocksAction(a cc.Action) *GenerateBlocksAction {
+	return &GenerateBlocksAction{action: a}
+}
+func (gba *GenerateBlocksAction) Compute(pm *cc.PluginManager) error {
+	a := gba.action
+	outputDataSourceKey := a.Attributes.GetStringOrFail("output_data_source")
+	realizations := a.Attributes.GetIntOrFail("realizations")
+	blocksPerRealization := a.Attributes.GetIntOrFail("blocks_per_realization")
+	rates, err := a.Attributes.GetMap("arrival_rates")
+	if err != nil {
+		return err
+	}
+	model, err := utils.ArrivalModelFromAttributes(rates)
+	if err != nil {
+		return err
+	}
+	seeds, err := utils.GetSeeds(a)
+	if err != nil {
+		return err
+	}
+	//the seed table has one row per event, the block seed of a realization is on the first event row of the realization.
+	blockSeeds := utils.RealizationBlockSeeds(seeds)
+	if len(blockSeeds) < realizations {
+		return fmt.Errorf("found %v realizations in the seed sets but %v realizations were requested", len(blockSeeds), realizations)
+	}
+	blocks, typeCounts, err := utils.GenerateBlocks(model, blockSeeds[:realizations], blocksPerRealization)
+	if err != nil {
+		return err
+	}
+	totals := utils.StormTypeTotals(typeCounts)
+	for _, r := range model {
+		pm.Logger.Info(fmt.Sprintf("storm type %v generated %v events across %v realizations", r.StormType, totals[r.StormType], realizations))
+	}
+	//optional storm type counts of each block, without them only the total arrival rate reaches full_simulation_sst.
+	typeCountsFile := a.Attributes.GetStringOrDefault("block_storm_types_file", "")
+	if typeCountsFile != "" {
+		err = putStoreFile(pm, a.Attributes.GetStringOrFail("block_storm_types_store"), typeCountsFile, utils.BlockStormTypeCountsToBytes(typeCounts))
+		if err != nil {
+			return err
+		}
+	}
+	return utils.PutBlocks(pm, a, outputDataSourceKey, blocks)
+}
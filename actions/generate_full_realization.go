@@ -0,0 +1,117 @@
+package actions
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+/*
+This action generates the seeds, blocks and events for a full simulation from a single master seed so that small studies
+can run without the upstream seed generator.
+//steps:
+1. derive a block seed stream and one seed stream per seed column from the master seed
+2. generate blocks for each realization from the arrival rate model (see generate_blocks)
+3. generate event seeds for every event in the blocks for each seed column
+4. run the full_simulation_sst logic with the hms-mutator seeds and the blocks
+5. store seeds, blocks and events in the tiledb store with the layout the existing readers expect
+*/
+type GenerateFullRealizationAction struct {
+	action cc.Action
+}
+
+func InitGenerateFullRealizationAction(a cc.Action) *GenerateFullRealizationAction {
+	return &GenerateFullRealizationAction{action: a}
+}
+func (gfra *GenerateFullRealizationAction) Compute(pm *cc.PluginManager) error {
+	a := gfra.action
+	masterSeed := a.Attributes.GetInt64OrFail("master_seed")
+	realizations := a.Attributes.GetIntOrFail("realizations")
+	yearsPerRealization := a.Attributes.GetIntOrFail("years_per_realization")
+	seedColumns := []string{pluginName}
+	if _, ok := a.Attributes["seed_columns"]; ok {
+		columns, err := a.Attributes.GetStringSlice("seed_columns")
+		if err != nil {
+			return err
+		}
+		seedColumns = columns
+	}
+	hmsMutatorColumn := slices.Index(seedColumns, pluginName)
+	if hmsMutatorColumn == -1 {
+		return fmt.Errorf("seed_columns must include %v", pluginName)
+	}
+	rates, err := a.Attributes.GetMap("arrival_rates")
+	if err != nil {
+		return err
+	}
+	model, err := utils.ArrivalModelFromAttributes(rates)
+	if err != nil {
+		return err
+	}
+	seedsOutput, err := a.GetOutputDataSource(a.Attributes.GetStringOrFail("seeds_output_data_source"))
+	if err != nil {
+		return err
+	}
+	blocksOutput, err := a.GetOutputDataSource(a.Attributes.GetStringOrFail("blocks_output_data_source"))
+	if err != nil {
+		return err
+	}
+	eventsOutput, err := a.GetOutputDataSource(a.Attributes.GetStringOrFail("output_data_source"))
+	if err != nil {
+		return err
+	}
+	inputs, err := readFullSimulationInputs(a)
+	if err != nil {
+		return err
+	}
+
+	//the block stream is drawn first so blocks do not depend on the number of seed columns.
+	masterRng := rand.New(rand.NewSource(masterSeed))
+	blockRng := rand.New(rand.NewSource(masterRng.Int63()))
+	realizationBlockSeeds := make([]int64, realizations)
+	for i := range realizationBlockSeeds {
+		realizationBlockSeeds[i] = blockRng.Int63()
+	}
+	blocks, typeCounts, err := utils.GenerateBlocks(model, realizationBlockSeeds, yearsPerRealization)
+	if err != nil {
+		return err
+	}
+	//events are sampled from the storms of the storm type the arrival model drew for them.
+	inputs.blockStormTypes = typeCounts
+	totals := utils.StormTypeTotals(typeCounts)
+	for _, r := range model {
+		pm.Logger.Info(fmt.Sprintf("storm type %v generated %v events across %v realizations", r.StormType, totals[r.StormType], realizations))
+	}
+	seeds := make([][]utils.SeedSet, len(seedColumns))
+	for i := range seedColumns {
+		seeds[i] = utils.GenerateRealizationSeeds(masterRng.Int63(), realizationBlockSeeds, blocks)
+	}
+
+	results, summary, err := compute(inputs, seeds[hmsMutatorColumn], blocks)
+	if err != nil {
+		return err
+	}
+	err = reportPathValidation(pm, a, inputs, summary)
+	if err != nil {
+		return err
+	}
+
+	//write seeds, blocks and events to the store.
+	seedStore, err := a.GetStore(seedsOutput.StoreName)
+	if err != nil {
+		return err
+	}
+	//the seed reader expects the seed array to be named after the seeds datasource.
+	err = utils.NewTileDbSeedWriter(seedStore, seedsOutput.Name, seedColumns).Write(seeds)
+	if err != nil {
+		return err
+	}
+	err = utils.NewTileDbBlockWriter(pm, blocksOutput.StoreName, blocksOutput.Name).Write(blocks)
+	if err != nil {
+		return err
+	}
+	return writeResultsToTileDB(pm, eventsOutput.StoreName, results, eventsOutput.Name, inputs.eventSchemaVersion)
+}
@@ -0,0 +1,111 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/hms"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+//the objective of this action is to set up an hms uncertainty analysis (*.mca) for an event.
+//the seed is set from the realization seed so parameter uncertainty is consistent within a realization (knowledge uncertainty),
+//the number of realizations is set from an attribute or a realizations table, and parameter sampling specifications can be edited.
+
+// McaParameterUpdate is a set of property edits to the sampling specification of a basin element parameter.
+type McaParameterUpdate struct {
+	Element    string
+	Parameter  string
+	Properties map[string]string
+}
+
+type McaMutationAction struct {
+	action       cc.Action
+	seedSet      utils.SeedSet
+	mca          hms.Mca
+	realizations *hms.Realizations
+}
+
+func InitMcaMutationAction(action cc.Action, seedSet utils.SeedSet, mca hms.Mca, realizations *hms.Realizations) *McaMutationAction {
+	return &McaMutationAction{
+		action:       action,
+		seedSet:      seedSet,
+		mca:          mca,
+		realizations: realizations,
+	}
+}
+
+// Compute updates the seed, realizations and parameter specifications and returns the updated *.mca bytes.
+func (mma *McaMutationAction) Compute() ([]byte, error) {
+	a := mma.action
+	m := &mma.mca
+	var err error
+	if mma.realizations != nil {
+		eventNumber := a.Attributes.GetInt64OrDefault("event_number", 0)
+		stormName := a.Attributes.GetStringOrDefault("storm_name", "")
+		err = m.UpdateEvent(*mma.realizations, eventNumber, stormName, mma.seedSet.RealizationSeed)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = m.UpdateSeed(mma.seedSet.RealizationSeed)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := a.Attributes["realizations"]; ok {
+			err = m.UpdateRealizations(a.Attributes.GetIntOrFail("realizations"))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	updates, err := readParameterUpdates(a)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range updates {
+		err = m.UpdateParameter(u.Element, u.Parameter, u.Properties)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m.ToBytes(), nil
+}
+
+// readParameterUpdates reads the parameter_updates attribute, a list of {"element": "", "parameter": "", "properties": {"Mean": "0.2"}}
+func readParameterUpdates(a cc.Action) ([]McaParameterUpdate, error) {
+	updates := make([]McaParameterUpdate, 0)
+	raw, ok := a.Attributes["parameter_updates"]
+	if !ok {
+		return updates, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return updates, fmt.Errorf("parameter_updates must be a list")
+	}
+	for i, item := range list {
+		definition, ok := item.(map[string]any)
+		if !ok {
+			return updates, fmt.Errorf("parameter update %v is not an object", i)
+		}
+		attrs := cc.PayloadAttributes(definition)
+		element, err := attrs.GetString("element")
+		if err != nil {
+			return updates, fmt.Errorf("parameter update %v requires an element", i)
+		}
+		parameter, err := attrs.GetString("parameter")
+		if err != nil {
+			return updates, fmt.Errorf("parameter update %v requires a parameter", i)
+		}
+		properties, err := attrs.GetMap("properties")
+		if err != nil {
+			return updates, fmt.Errorf("parameter update %v requires properties", i)
+		}
+		u := McaParameterUpdate{Element: element, Parameter: parameter, Properties: make(map[string]string)}
+		for k, v := range properties {
+			u.Properties[k] = fmt.Sprint(v)
+		}
+		updates = append(updates, u)
+	}
+	return updates, nil
+}
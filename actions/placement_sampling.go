@@ -0,0 +1,33 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+// defaultPlacementUniformFraction is the share of the placement density kept uniform when importance sampling.
+const defaultPlacementUniformFraction float64 = .1
+
+// PlacementDensityFromAttributes reads the placement_sampling attributes shared by full_simulation_sst and single_stochastic_transposition,
+// placements are sampled uniformly if placement_sampling is not provided and a truncated normal requires placement_center_x and placement_center_y.
+func PlacementDensityFromAttributes(attributes cc.PayloadAttributes) (utils.PlacementDensity, error) {
+	density := utils.PlacementDensity{
+		Method:            utils.PlacementSampling(attributes.GetStringOrDefault("placement_sampling", string(utils.UniformPlacementSampling))),
+		StandardDeviation: attributes.GetFloatOrDefault("placement_standard_deviation", 0),
+		UniformFraction:   attributes.GetFloatOrDefault("placement_uniform_fraction", defaultPlacementUniformFraction),
+	}
+	_, hasX := attributes["placement_center_x"]
+	_, hasY := attributes["placement_center_y"]
+	if hasX != hasY {
+		return density, fmt.Errorf("placement_center_x and placement_center_y must be provided together")
+	}
+	if density.Method == utils.TruncatedNormalPlacementSampling && !hasX {
+		return density, fmt.Errorf("truncated_normal placement sampling requires placement_center_x and placement_center_y")
+	}
+	if hasX {
+		density.Center = utils.Coordinate{X: attributes.GetFloatOrFail("placement_center_x"), Y: attributes.GetFloatOrFail("placement_center_y")}
+	}
+	return density, density.Validate()
+}
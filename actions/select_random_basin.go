@@ -15,6 +15,20 @@ import (
 //this allows the basin parameterization to be randomized and the anticedent conditions to be randomized.
 //for simplicty, the process is based on an indexed list of basin files, that are selected randomly
 //downloaded to the container, and then uploaded with a new name to the event ouptut destination.
+//alternatively the basin can be selected by date, the antecedent condition basin is sampled for the storm type with the seasonality
+//distributions, period of record and calibration events of full_simulation_sst, so both actions select the same basin for an event seed.
+
+const (
+	UniformBasinSelection string = "uniform"
+	DateBasinSelection    string = "date"
+)
+
+// SelectedBasin is the sampled basin and control, the control start is used to compute the transposition time shift.
+type SelectedBasin struct {
+	BasinBytes   []byte
+	ControlBytes []byte
+	ControlStart time.Time
+}
 
 type SelectBasinAction struct {
 	action   cc.Action
@@ -33,75 +47,200 @@ func InitSelectBasinAction(action cc.Action, seedSet utils.SeedSet, inputDs cc.D
 	return &sba
 }
 func (sba SelectBasinAction) Compute() (time.Time, error) {
-	//get range of basin scenarios (ints between 0 and n?)
-	maxbasinid := sba.action.Attributes.GetIntOrFail("maxBasinId")
+	selected, err := sba.Sample(sba.action.Attributes.GetStringOrDefault("stormType", ""))
+	if err != nil {
+		return time.Now(), err
+	}
+	err = sba.Put(selected)
+	if err != nil {
+		return time.Now(), err
+	}
+	return selected.ControlStart, nil
+}
+
+// Sample selects a basin and control and updates the control window without writing outputs. The storm type is used by date basin
+// selection, it is empty if a storm has not been transposed and the stormType attribute is not provided.
+func (sba SelectBasinAction) Sample(stormType string) (SelectedBasin, error) {
 	basinExtension := sba.action.Attributes.GetStringOrFail("basinExtension")
-	targetBasinFileName := sba.action.Attributes.GetStringOrFail("targetBasinFileName")
 	controlExtension := sba.action.Attributes.GetStringOrFail("controlExtension")
-	targetControlFileName := sba.action.Attributes.GetStringOrFail("targetControlFileName")
 	//allowing user specified start date to accommodate the inclusion of a setback period.
 	updateStartDateAndTime, err := strconv.ParseBool(sba.action.Attributes.GetStringOrFail("updateStartDateAndTime"))
 	if err != nil {
-		return time.Now(), err
+		return SelectedBasin{}, err
 	}
 	hoursOffset := sba.action.Attributes.GetIntOrDefault("startDateAndTimeOffset", 0)
+	//optional window management, the end is set relative to the (offset) start so the window covers the storm.
+	simulationDurationHours := sba.action.Attributes.GetIntOrDefault("simulationDurationHours", 0)
+	timeInterval := sba.action.Attributes.GetIntOrDefault("timeInterval", 0)
+	timeZone := sba.action.Attributes.GetStringOrDefault("timeZone", "")
 
 	//generate a natural variabiilty seed generator
 	rng := rand.New(rand.NewSource(sba.seedSet.EventSeed))
 
-	//sample an int in the range of basin scenarios
-	sampledBasinId := rng.Int31n(int32(maxbasinid)) //0 to exclusive upper bound
 	//download the file from filesapi
 	pm, err := cc.InitPluginManager()
 	if err != nil {
-		return time.Now(), err
+		return SelectedBasin{}, err
 	}
 	inDS := sba.inputDS
 	inDSRoot := inDS.Paths["default"]
-	inDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", inDSRoot, fmt.Sprint(sampledBasinId), basinExtension)
-	//fmt.Println(inDS.Paths["default"])
-	basinbytes, err := utils.GetFile(*pm, sba.inputDS, "default") //pm.GetFile(sba.inputDS, 0)
+	sampledBasinName, err := sba.sampleBasinName(rng, pm, inDSRoot, basinExtension, stormType)
 	if err != nil {
-		return time.Now(), err
+		return SelectedBasin{}, err
 	}
-	//upload the file to filesapi with the appropriate new name.
-	outDS := sba.outputDS
-	outDSRoot := outDS.Paths["default"]
-	outDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", outDSRoot, targetBasinFileName, basinExtension)
-	//fmt.Println(outDS.Paths["default"])
-	err = utils.PutFile(basinbytes, pm.IOManager, sba.outputDS, "default")
+	inDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", inDSRoot, sampledBasinName, basinExtension)
+	//fmt.Println(inDS.Paths["default"])
+	basinbytes, err := utils.GetFile(*pm, sba.inputDS, "default") //pm.GetFile(sba.inputDS, 0)
 	if err != nil {
-		return time.Now(), err
+		return SelectedBasin{}, err
 	}
 
-	inDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", inDSRoot, fmt.Sprint(sampledBasinId), controlExtension)
+	inDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", inDSRoot, sampledBasinName, controlExtension)
 	//fmt.Println(inDS.Paths["default"])
 	controlbytes, err := utils.GetFile(*pm, sba.inputDS, "default")
 	if err != nil {
-		return time.Now(), err
+		return SelectedBasin{}, err
 	}
 	control, err := hms.ReadControl(controlbytes)
 	if err != nil {
-		return time.Now(), err
+		return SelectedBasin{}, err
 	}
 	controltime, err := control.StartDateAndTime()
 	if err != nil {
-		return controltime, err
+		return SelectedBasin{}, err
+	}
+	if timeInterval > 0 {
+		control.TimeInterval = timeInterval
+	}
+	if timeZone != "" {
+		err = control.SetTimeZone(timeZone)
+		if err != nil {
+			return SelectedBasin{}, err
+		}
 	}
-	if updateStartDateAndTime {
+	if simulationDurationHours > 0 {
+		if updateStartDateAndTime {
+			controltime = controltime.Add(time.Duration(hoursOffset) * time.Hour)
+		}
+		err = control.SetWindow(controltime, controltime.Add(time.Duration(simulationDurationHours)*time.Hour))
+		if err != nil {
+			return SelectedBasin{}, err
+		}
+	} else if updateStartDateAndTime && control.EndDate != "" {
+		//the end moves with the start so the window keeps its duration.
+		controltime, err = control.ShiftWindow(hoursOffset)
+		if err != nil {
+			return SelectedBasin{}, err
+		}
+	} else if updateStartDateAndTime {
 		controltime, err = control.AddHoursToStart(hoursOffset)
 		if err != nil {
-			return time.Now(), err
+			return SelectedBasin{}, err
 		}
+	}
+	if updateStartDateAndTime || simulationDurationHours > 0 || timeInterval > 0 || timeZone != "" {
 		controlbytes = control.ToBytes()
 	}
+	return SelectedBasin{BasinBytes: basinbytes, ControlBytes: controlbytes, ControlStart: controltime}, nil
+}
+
+// sampleBasinName selects the basin (and control) file name without an extension.
+func (sba SelectBasinAction) sampleBasinName(rng *rand.Rand, pm *cc.PluginManager, root string, basinExtension string, stormType string) (string, error) {
+	selection := sba.action.Attributes.GetStringOrDefault("basinSelection", UniformBasinSelection)
+	switch selection {
+	case UniformBasinSelection:
+		//get range of basin scenarios (ints between 0 and n?)
+		maxbasinid := sba.action.Attributes.GetIntOrFail("maxBasinId")
+		//sample an int in the range of basin scenarios
+		sampledBasinId := rng.Int31n(int32(maxbasinid)) //0 to exclusive upper bound
+		return fmt.Sprint(sampledBasinId), nil
+	case DateBasinSelection:
+		if stormType == "" {
+			return "", fmt.Errorf("basinSelection %v requires the storm type, provide the stormType attribute or use the compose_event action", DateBasinSelection)
+		}
+		sampler, err := sba.antecedentSampler(pm)
+		if err != nil {
+			return "", err
+		}
+		basin, err := sampler.sample(antecedentRng(sba.seedSet.EventSeed), stormType)
+		if err != nil {
+			return "", err
+		}
+		if !sba.action.Attributes.GetBooleanOrDefault("useNearestBasin", false) {
+			return basin.Name(), nil
+		}
+		//a missing basin is replaced by the basin with the nearest date for the calibration event.
+		fileNames, err := utils.ListAllPaths(pm.IOManager, sba.inputDS.StoreName, root, fmt.Sprintf("*.%v", basinExtension))
+		if err != nil {
+			return "", err
+		}
+		basins := utils.ParseAntecedentBasins(fileNames, sampler.basinName)
+		for _, b := range basins {
+			if b.Name() == basin.Name() {
+				return basin.Name(), nil
+			}
+		}
+		nearest, err := utils.NearestAntecedentBasin(basins, basin.Date, basin.CalibrationEvent, false)
+		if err != nil {
+			return "", err
+		}
+		return nearest.Name(), nil
+	default:
+		return "", fmt.Errorf("unsupported basinSelection %v, expected %v or %v", selection, UniformBasinSelection, DateBasinSelection)
+	}
+}
 
+// antecedentSampler reads the seasonality distributions, period of record and calibration events date basin selection samples from.
+func (sba SelectBasinAction) antecedentSampler(pm *cc.PluginManager) (antecedentSampler, error) {
+	sampler := antecedentSampler{basinName: sba.action.Attributes.GetStringOrFail("basinName")}
+	var err error
+	sampler.calibrationEventNames, err = sba.action.Attributes.GetStringSlice("calibrationEventNames")
+	if err != nil {
+		return sampler, err
+	}
+	if len(sampler.calibrationEventNames) == 0 {
+		return sampler, fmt.Errorf("calibrationEventNames must include at least one calibration event")
+	}
+	sampler.porStart, err = time.Parse("20060102", sba.action.Attributes.GetStringOrFail("porStartDate"))
+	if err != nil {
+		return sampler, err
+	}
+	sampler.porEnd, err = time.Parse("20060102", sba.action.Attributes.GetStringOrFail("porEndDate"))
+	if err != nil {
+		return sampler, err
+	}
+	directory := sba.action.Attributes.GetStringOrFail("stormTypeSeasonalityDistributionDirectory")
+	storeKey := sba.action.Attributes.GetStringOrDefault("stormTypeSeasonalityDistributionStore", sba.inputDS.StoreName)
+	distributionList, err := utils.ListAllPaths(pm.IOManager, storeKey, directory, "*.csv")
+	if err != nil {
+		return sampler, err
+	}
+	sampler.seasonalDistributions, err = utils.ReadStormDistributions(pm.IOManager, storeKey, distributionList, directory)
+	return sampler, err
+}
+
+// Put uploads the selected basin and control to the output datasource with the target file names.
+func (sba SelectBasinAction) Put(selected SelectedBasin) error {
+	basinExtension := sba.action.Attributes.GetStringOrFail("basinExtension")
+	targetBasinFileName := sba.action.Attributes.GetStringOrFail("targetBasinFileName")
+	controlExtension := sba.action.Attributes.GetStringOrFail("controlExtension")
+	targetControlFileName := sba.action.Attributes.GetStringOrFail("targetControlFileName")
+	pm, err := cc.InitPluginManager()
+	if err != nil {
+		return err
+	}
 	//upload the file to filesapi with the appropriate new name.
-	outDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", outDSRoot, targetControlFileName, controlExtension)
-	fmt.Println(outDS.Paths["default"])
-	err = utils.PutFile(controlbytes, pm.IOManager, sba.outputDS, "default")
+	outDS := sba.outputDS
+	outDSRoot := outDS.Paths["default"]
+	defer func() { outDS.Paths["default"] = outDSRoot }()
+	outDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", outDSRoot, targetBasinFileName, basinExtension)
+	//fmt.Println(outDS.Paths["default"])
+	err = utils.PutFile(selected.BasinBytes, pm.IOManager, sba.outputDS, "default")
 	if err != nil {
-		return time.Now(), err
+		return err
 	}
-	return controltime, nil
+	//upload the file to filesapi with the appropriate new name.
+	outDS.Paths["default"] = fmt.Sprintf("%v/%v.%v", outDSRoot, targetControlFileName, controlExtension)
+	fmt.Println(outDS.Paths["default"])
+	return utils.PutFile(selected.ControlBytes, pm.IOManager, sba.outputDS, "default")
 }
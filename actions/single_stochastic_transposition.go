@@ -18,14 +18,21 @@ type SingleStochasticTransposition struct {
 	seedSet                  utils.SeedSet
 	transpositionDomainBytes []byte
 	watershedBytes           []byte
+	domainSelections         utils.DomainSelections
+	terrain                  utils.TerrainConstraint
+	stormWeights             utils.StormWeights
+	companionGridTypes       []string
+	placementDensity         utils.PlacementDensity
 }
 type StochasticTranspositionResult struct {
-	MetBytes  []byte
-	GridBytes []byte
-	StormName string
+	MetBytes        []byte
+	GridBytes       []byte
+	StormName       string
+	StormWeight     float64
+	PlacementWeight float64 //unnormalized likelihood ratio of the placement, one unless placements are importance sampled.
 }
 
-func InitSingleStochasticTransposition(pm *cc.PluginManager, gridFile hms.GridFile, metFile hms.Met, seedSet utils.SeedSet, tbytes []byte, wbytes []byte) SingleStochasticTransposition {
+func InitSingleStochasticTransposition(pm *cc.PluginManager, gridFile hms.GridFile, metFile hms.Met, seedSet utils.SeedSet, tbytes []byte, wbytes []byte, domainSelections utils.DomainSelections, terrain utils.TerrainConstraint, stormWeights utils.StormWeights, companionGridTypes []string, placementDensity utils.PlacementDensity) SingleStochasticTransposition {
 	return SingleStochasticTransposition{
 		pm:                       pm,
 		gridFile:                 gridFile,
@@ -33,38 +40,128 @@ func InitSingleStochasticTransposition(pm *cc.PluginManager, gridFile hms.GridFi
 		seedSet:                  seedSet,
 		transpositionDomainBytes: tbytes,
 		watershedBytes:           wbytes,
+		domainSelections:         domainSelections,
+		terrain:                  terrain,
+		stormWeights:             stormWeights,
+		companionGridTypes:       companionGridTypes,
+		placementDensity:         placementDensity,
 	}
 }
-func (sst SingleStochasticTransposition) Compute(bootstrapCatalog bool, bootstrapCatalogLength int, normalize bool, controlStartTime time.Time, userSpecifiedOffset int) (StochasticTranspositionResult, error) {
+func (sst SingleStochasticTransposition) Compute(bootstrapCatalog bool, bootstrapOptions hms.BootstrapOptions, normalize bool, controlStartTime time.Time, userSpecifiedOffset int) (StochasticTranspositionResult, error) {
+	storm, err := sst.Transpose(bootstrapCatalog, bootstrapOptions)
+	if err != nil {
+		return StochasticTranspositionResult{}, err
+	}
+	return sst.TimeShift(storm, normalize, controlStartTime, userSpecifiedOffset)
+}
+
+// TransposedStorm is the selected and transposed storm before the met time shift is computed, the storm type can be used to select
+// the basin and control before the time shift is computed relative to the control start.
+type TransposedStorm struct {
+	StormStart      time.Time
+	StormType       string
+	met             hms.Met
+	storm           hms.PrecipGridEvent
+	temperature     hms.TempGridEvent
+	companions      []hms.CompanionGridEvent
+	gridBytes       []byte
+	stormName       string
+	stormWeight     float64
+	placementWeight float64
+}
+
+// Transpose selects and transposes a storm from the seed set and pairs its temperature and companion grids.
+func (sst SingleStochasticTransposition) Transpose(bootstrapCatalog bool, bootstrapOptions hms.BootstrapOptions) (TransposedStorm, error) {
 	//initialize simulation
-	var ge hms.PrecipGridEvent
-	var te hms.TempGridEvent
-	var m hms.Met
-	var gfbytes []byte
-	var originalDssPath string
-	sim, err := transposition.InitTranspositionSimulation(sst.transpositionDomainBytes, sst.watershedBytes, sst.metFile, sst.gridFile)
+	var ts TransposedStorm
+	//companion grids are only written with the storm they are paired with.
+	gridFile, err := sst.gridFile.ExtractCompanions(sst.companionGridTypes)
 	if err != nil {
 		sst.pm.Logger.Error(err.Error())
-		return StochasticTranspositionResult{}, err
+		return ts, err
+	}
+	sim, err := transposition.InitTranspositionSimulation(sst.transpositionDomainBytes, sst.watershedBytes, sst.domainSelections, sst.metFile, gridFile)
+	if err != nil {
+		sst.pm.Logger.Error(err.Error())
+		return ts, err
+	}
+	err = sim.SetPlacementDensity(sst.placementDensity)
+	if err != nil {
+		sst.pm.Logger.Error(err.Error())
+		return ts, err
+	}
+	err = sim.SetTerrainConstraint(sst.terrain)
+	if err != nil {
+		sst.pm.Logger.Error(err.Error())
+		return ts, err
 	}
 	//compute simulation for given seed set
-	m, ge, te, err = sim.Compute(sst.seedSet.EventSeed, sst.seedSet.RealizationSeed, bootstrapCatalog, bootstrapCatalogLength)
+	ts.met, ts.storm, ts.temperature, ts.stormWeight, ts.placementWeight, err = sim.Compute(sst.seedSet.EventSeed, sst.seedSet.RealizationSeed, bootstrapCatalog, bootstrapOptions, sst.stormWeights)
 	if err != nil {
 		sst.pm.Logger.Error(err.Error())
-		return StochasticTranspositionResult{}, err
+		return ts, err
 	}
-	originalDssPath, _ = ge.OriginalDSSFile()
+	ts.stormName, _ = ts.storm.OriginalDSSFile()
+	ts.StormType = ts.storm.StormType()
 	//update the dss file output to match the agreed upon convention /data/Storm.dss
-	ge.UpdateDSSFile("Storm")
-	te.UpdateDSSFile("Storm")
-	gfbytes = sim.GetGridFileBytes(ge, te)
-	geStartTime, err := time.Parse("02Jan2006:1504", ge.StartTime)
+	ts.storm.UpdateDSSFile("Storm")
+	ts.temperature.UpdateDSSFile("Storm")
+	ts.companions, err = gridFile.PairedCompanions(ts.storm)
 	if err != nil {
 		sst.pm.Logger.Error(err.Error())
-		return StochasticTranspositionResult{}, err
+		return ts, err
+	}
+	for i := range ts.companions {
+		ts.companions[i].UpdateDSSFile("Storm")
 	}
+	ts.gridBytes = sim.GetGridFileBytes(ts.storm, ts.temperature, ts.companions...)
+	ts.StormStart, err = time.Parse("02Jan2006:1504", ts.storm.StartTime)
+	if err != nil {
+		sst.pm.Logger.Error(err.Error())
+		return ts, err
+	}
+	return ts, nil
+}
+
+// TimeShift computes the met time shift of the transposed storm relative to the control start and writes the met file.
+func (sst SingleStochasticTransposition) TimeShift(ts TransposedStorm, normalize bool, controlStartTime time.Time, userSpecifiedOffset int) (StochasticTranspositionResult, error) {
+	m := ts.met
+	geStartTime := ts.StormStart
+	var err error
 	//get met file bytes
 	m.UpdatePrecipTimeShift(normalize, controlStartTime, geStartTime, userSpecifiedOffset)
+	if ts.temperature.Name != "" {
+		//temperature is shifted with precipitation, a temperature grid without a start time is assumed to start with the storm.
+		teStartTime := geStartTime
+		if ts.temperature.StartTime != "" {
+			teStartTime, err = time.Parse("02Jan2006:1504", ts.temperature.StartTime)
+			if err != nil {
+				sst.pm.Logger.Error(err.Error())
+				return StochasticTranspositionResult{}, err
+			}
+		}
+		err = m.UpdateTempTimeShift(normalize, controlStartTime, teStartTime, userSpecifiedOffset)
+		if err != nil {
+			sst.pm.Logger.Error(err.Error())
+			return StochasticTranspositionResult{}, err
+		}
+	}
+	for _, c := range ts.companions {
+		//companion grids are shifted with precipitation the same way as temperature.
+		cStartTime := geStartTime
+		if c.StartTime != "" {
+			cStartTime, err = time.Parse("02Jan2006:1504", c.StartTime)
+			if err != nil {
+				sst.pm.Logger.Error(err.Error())
+				return StochasticTranspositionResult{}, err
+			}
+		}
+		err = m.UpdateCompanionGrid(c.Type, c.Name, normalize, controlStartTime, cStartTime, userSpecifiedOffset)
+		if err != nil {
+			sst.pm.Logger.Error(err.Error())
+			return StochasticTranspositionResult{}, err
+		}
+	}
 	mbytes, err := m.WriteBytes()
 	if err != nil {
 		sst.pm.Logger.Error(err.Error())
@@ -72,9 +169,11 @@ func (sst SingleStochasticTransposition) Compute(bootstrapCatalog bool, bootstra
 	}
 	// prepare result
 	result := StochasticTranspositionResult{
-		MetBytes:  mbytes,
-		GridBytes: gfbytes,
-		StormName: originalDssPath,
+		MetBytes:        mbytes,
+		GridBytes:       ts.gridBytes,
+		StormName:       ts.stormName,
+		StormWeight:     ts.stormWeight,
+		PlacementWeight: ts.placementWeight,
 	}
 	return result, nil
 	//find the right resource locations
@@ -3,9 +3,9 @@ package actions
 import (
 	"errors"
 	"fmt"
-	"math"
 	"math/rand"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,7 +23,13 @@ type StratifiedCompute struct {
 	GridFile                 hms.GridFile
 	TranspositionPolygon     gdal.DataSource //ideally this would be the buffered transposition domain to represent valid transposition locations.
 	StudyAreaPolygon         gdal.DataSource
+	TranspositionDomains     utils.DomainSet //the selected features of the transposition polygon, keyed by storm type if transposition_storm_type_field is provided.
+	StudyArea                utils.Domain    //the selected features of the study area polygon.
 	AcceptanceDepthThreshold float64
+	Pattern                  utils.FishnetPattern    //arrangement of the candidate locations, square by default.
+	Seed                     int64                   //seed for the jittered pattern.
+	Terrain                  utils.TerrainConstraint //placements are not constrained by terrain unless it is set.
+	Logger                   *cc.CcLogger            //progress is not logged if it is nil.
 }
 type StratifiedComputeResult struct {
 	CandiateLocations utils.CoordinateList
@@ -37,6 +43,7 @@ type LocationInfo struct {
 	StormName  string
 	Coordinate utils.Coordinate
 	IsValid    bool
+	Statistics utils.PlacementStatistics //depth statistics over the watershed, only computed by the raster placement engine.
 }
 
 const LOCALDIR = "/app/data/"
@@ -59,9 +66,20 @@ func InitStratifiedCompute(a cc.Action, gridfile hms.GridFile, polygonBytes []by
 	}
 	tds := gdal.OpenDataSource(filePath, 0)  //defer disposing the datasource and layers.
 	wds := gdal.OpenDataSource(wfilePath, 0) //defer disposing the datasource and layers.
+	selections := DomainSelectionsFromAttributes(a.Attributes)
+	transpositionDomains, err := utils.ReadDomainSet(tds, selections.TranspositionRegion)
+	if err != nil {
+		return StratifiedCompute{}, fmt.Errorf("could not read the transposition region: %v", err)
+	}
+	studyArea, err := utils.ReadDomain(wds, selections.WatershedBoundary)
+	if err != nil {
+		return StratifiedCompute{}, fmt.Errorf("could not read the watershed boundary: %v", err)
+	}
 	spacing := a.Attributes.GetFloatOrFail("spacing")
 	acceptance_threshold := a.Attributes.GetFloatOrFail("acceptance_threshold")
-	return StratifiedCompute{Spacing: spacing, GridFile: gridfile, TranspositionPolygon: tds, StudyAreaPolygon: wds, AcceptanceDepthThreshold: acceptance_threshold}, nil
+	pattern := utils.FishnetPattern(a.Attributes.GetStringOrDefault("fishnet_pattern", string(utils.SquareFishnet)))
+	seed := a.Attributes.GetInt64OrDefault("fishnet_seed", 1234)
+	return StratifiedCompute{Spacing: spacing, GridFile: gridfile, TranspositionPolygon: tds, StudyAreaPolygon: wds, TranspositionDomains: transpositionDomains, StudyArea: studyArea, AcceptanceDepthThreshold: acceptance_threshold, Pattern: pattern, Seed: seed}, nil
 }
 func (sc StratifiedCompute) Compute() (StratifiedComputeResult, error) {
 	centers, err := sc.generateStormCenters() //still need to upload storm centers to the proper output location specified by the plugin manager.
@@ -80,6 +98,21 @@ func (sc StratifiedCompute) Compute() (StratifiedComputeResult, error) {
 	}
 	return result, nil
 }
+
+// SetTerrainConstraint rejects placements that fail the terrain constraint in both placement engines.
+func (sc *StratifiedCompute) SetTerrainConstraint(tc utils.TerrainConstraint) error {
+	tc, err := tc.ForWatershed(sc.StudyArea)
+	if err != nil {
+		return fmt.Errorf("could not constrain placements by terrain: %v", err)
+	}
+	sc.Terrain = tc
+	return nil
+}
+
+// DetermineValidLocations evaluates every candidate location for every storm with the raster placement engine. Each storm's total
+// depth raster (<root>/<storm date>.tif) is read into memory once and the watershed is precomputed as a footprint of raster cell centers,
+// so a placement only indexes the depth array at the shifted footprint. A placement is valid if every watershed cell has data and
+// at least one cell is greater than the acceptance threshold, the mean, max and volume of the depth over the watershed are reported for every placement.
 func (sc StratifiedCompute) DetermineValidLocations(inputRoot cc.DataSource) (ValidLocationsComputeResult, error) {
 	var computeResult ValidLocationsComputeResult
 	allStormsAllLocations := make([]LocationInfo, 0)
@@ -89,85 +122,48 @@ func (sc StratifiedCompute) DetermineValidLocations(inputRoot cc.DataSource) (Va
 	if err != nil {
 		return computeResult, err
 	}
-	//take list of cell centers for the study area to query grid for missing data
-	studyAreaCellCenters, err := generateUniformPointList(sc.StudyAreaPolygon, sc.Spacing)
-	if err != nil {
-		return computeResult, err
-	}
-	ref := gdal.CreateSpatialReference("")
-	ref.FromEPSG(5070)
-	outref := gdal.CreateSpatialReference("")
-	outref.FromEPSG(4326)
 	root := path.Dir(inputRoot.Paths["default"])
-	//could be a go routine at this level
+	var footprint utils.WatershedFootprint
+	var firstDepth utils.DepthRaster
 	//loop through the storms in the grid file(in order for simplicity)
-	stormcenterbytes := make([]byte, 0)
-	for _, storm := range sc.GridFile.Events {
-		//create a validlocation coordinate list.
-		validLocations := utils.CoordinateList{Coordinates: make([]utils.Coordinate, 0)}
-		//determine the center of the storm.
-
-		stormCenter, err := gdal.CreateFromWKT(fmt.Sprintf("Point (%v %v)\n", storm.CenterX, storm.CenterY), ref)
+	for i, storm := range sc.GridFile.Events {
+		depth, err := stormDepth(root, storm)
 		if err != nil {
 			return computeResult, err
 		}
-		err = stormCenter.TransformTo(outref)
-		if err != nil {
-			return computeResult, err
+		if i == 0 {
+			//storm rasters share a grid so the footprint is computed once.
+			footprint, err = utils.NewWatershedFootprint(sc.StudyArea, depth)
+			if err != nil {
+				return computeResult, err
+			}
+			firstDepth = depth
+		} else if err = depth.SameGrid(firstDepth); err != nil {
+			return computeResult, fmt.Errorf("storm %v: %v", storm.Name, err)
 		}
-		stormCoord := utils.Coordinate{X: stormCenter.Y(0), Y: stormCenter.X(0)}
-		stormcenterbytes = append(stormcenterbytes, fmt.Sprintf("%v,%v,%v\n", storm.Name, stormCoord.X, stormCoord.Y)...)
-		//determine the start date of the storm
-		startDate := strings.Split(storm.Name, " ")[1]
-		//create a vsis3 path to that tif
-		tr, err := utils.InitTifReader(fmt.Sprintf("%v/%v.tif", root, startDate)) //get root path from one of the input data sources?
+		_, stormCandidates, err := sc.stormCandidates(candidateStormCenters, storm)
 		if err != nil {
 			return computeResult, err
 		}
-		defer tr.Close()
-
-		//fmt.Println(time.Now())
-		//loop through each point in the candidate storm centers
-		for _, candidate := range candidateStormCenters.Coordinates {
-			locationInfo := LocationInfo{
+		stormCoord := utils.Coordinate{X: storm.CenterX, Y: storm.CenterY}
+		stats := depth.PlaceAll(footprint, stormCoord, stormCandidates.Coordinates, sc.AcceptanceDepthThreshold)
+		validLocations := utils.CoordinateList{Coordinates: make([]utils.Coordinate, 0)}
+		for j, candidate := range stormCandidates.Coordinates {
+			stats[j].Valid = stats[j].Valid && sc.Terrain.Accept(stormCoord, candidate)
+			allStormsAllLocations = append(allStormsAllLocations, LocationInfo{
 				StormName:  storm.Name,
 				Coordinate: candidate,
-				IsValid:    false,
-			}
-			//calculate an offset from the center to the new destination location
-			offset := candidate.DetermineXandYOffset(stormCoord)
-			//invert that offset
-			offset.X = -offset.X
-			offset.Y = -offset.Y
-			//loop through each point in the cell centers for the study area
-			hasPrecipitation := false
-			hasNull := false
-			for _, cellCenter := range studyAreaCellCenters.Coordinates {
-				//offset the point by the inverted offset
-				cellCenter.ShiftPoint(offset)
-				//query the vsis3 tiff
-				value, err := tr.Query(cellCenter)
-				if err != nil {
-					//null or out of tif range, reject
-					hasNull = true
-					break //if data is null reject location
-				}
-				if value > sc.AcceptanceDepthThreshold { //if data is greater than 0 in any cell accept location
-					hasPrecipitation = true
-				}
-			}
-			if hasPrecipitation && !hasNull {
-				locationInfo.IsValid = true
+				IsValid:    stats[j].Valid,
+				Statistics: stats[j],
+			})
+			if stats[j].Valid {
 				validLocations.Coordinates = append(validLocations.Coordinates, candidate)
 			}
-			allStormsAllLocations = append(allStormsAllLocations, locationInfo)
-			//next cell center
-		} //next transposition location
-		validLocationMap[fmt.Sprintf("%v.csv", startDate)] = validLocations
+		}
+		validLocationMap[fmt.Sprintf("%v.csv", strings.Split(storm.Name, " ")[1])] = validLocations
 	} //next storm
 	computeResult.StormMap = validLocationMap
 	computeResult.AllStormsAllLocations = allStormsAllLocations
-	fmt.Println(string(stormcenterbytes))
 	return computeResult, nil
 }
 
@@ -187,19 +183,6 @@ func (sc StratifiedCompute) DetermineValidLocationsQuickly(iomanager cc.IOManage
 	if err != nil {
 		return computeResult, err
 	}
-	trp := sc.TranspositionPolygon.LayerByIndex(0).Feature(1)
-	//fmt.Println(trp.Geometry().GeometryCount())
-	//fmt.Println(trp.Geometry().Area())
-	sap := sc.StudyAreaPolygon.LayerByIndex(0).NextFeature()
-	defer sap.Destroy()
-	if sap.Geometry().Type() != 3 {
-		fmt.Println(sap.Geometry().Type())
-		return computeResult, errors.New("watershed boundary geometry not a simple polygon")
-	}
-	if sap.IsNull() {
-		fmt.Println("im null...")
-	}
-
 	ref := gdal.CreateSpatialReference("")
 	ref.FromEPSG(5070)
 
@@ -209,6 +192,15 @@ func (sc StratifiedCompute) DetermineValidLocationsQuickly(iomanager cc.IOManage
 	stormcenterbytes := make([]byte, 0)
 	names := make([]string, len(sc.GridFile.Events))
 	locationsslice := make([]utils.CoordinateList, len(sc.GridFile.Events))
+	//resolve the transposition domain of every storm type before the go routines start, an error returned inside a go routine is lost.
+	transpositionDomains := make([]utils.Domain, len(sc.GridFile.Events))
+	stormCandidatesSlice := make([]utils.CoordinateList, len(sc.GridFile.Events))
+	for i, storm := range sc.GridFile.Events {
+		transpositionDomains[i], stormCandidatesSlice[i], err = sc.stormCandidates(candidateStormCenters, storm)
+		if err != nil {
+			return computeResult, err
+		}
+	}
 	for i := 0; i < len(sc.GridFile.Events); i++ { //num, storm := range sc.GridFile.Events {
 		sem <- 1
 		go func(num int) error {
@@ -226,11 +218,13 @@ func (sc StratifiedCompute) DetermineValidLocationsQuickly(iomanager cc.IOManage
 			}
 
 			stormCoord := utils.Coordinate{X: stormCenter.X(0), Y: stormCenter.Y(0)}
+			transpositionDomain := transpositionDomains[num]
+			stormCandidates := stormCandidatesSlice[num]
 			stormcenterbytes = append(stormcenterbytes, fmt.Sprintf("%v,%v,%v\n", storm.Name, stormCoord.X, stormCoord.Y)...)
 			//fmt.Print(string(stormcenterbytes))
 			//fmt.Println(time.Now())
 			//loop through each point in the candidate storm centers
-			for _, candidate := range candidateStormCenters.Coordinates {
+			for _, candidate := range stormCandidates.Coordinates {
 				//fmt.Println(i)
 				locationInfo := LocationInfo{
 					StormName:  storm.Name,
@@ -243,25 +237,10 @@ func (sc StratifiedCompute) DetermineValidLocationsQuickly(iomanager cc.IOManage
 				//offset.X = -offset.X
 				//offset.Y = -offset.Y
 				func(shift utils.Coordinate) {
-					shiftableWatershedBoundary := sap.Geometry().Clone() //shift watershed boundary
+					shiftableWatershedBoundary := sc.StudyArea.Shifted(-shift.X, -shift.Y) //shift watershed boundary, every part of a multipolygon is shifted.
 					defer shiftableWatershedBoundary.Destroy()
-					geometrycount := shiftableWatershedBoundary.GeometryCount()
-					for g := 0; g < geometrycount; g++ {
-						geometry := shiftableWatershedBoundary.Geometry(g)
-						//defer geometry.Destroy()
-						geometryPointCount := geometry.PointCount()
-						//fmt.Printf("x,y\n")
-						for i := 0; i < geometryPointCount; i++ {
-							px, py, pz := geometry.Point(i)
-							shiftedx := px - shift.X
-							shiftedy := py - shift.Y
-							//fmt.Printf("%v,%v\n", shiftedx, shiftedy)
-							shiftableWatershedBoundary.Geometry(g).SetPoint(i, shiftedx, shiftedy, pz) //does this work or does it insert?
-						}
-					}
-
-					shiftContained := trp.Geometry().Contains(shiftableWatershedBoundary)
-					if shiftContained {
+					shiftContained := transpositionDomain.Geometry.Contains(shiftableWatershedBoundary)
+					if shiftContained && sc.Terrain.Accept(stormCoord, candidate) {
 						locationInfo.IsValid = true
 						validLocations.Coordinates = append(validLocations.Coordinates, candidate)
 					}
@@ -300,49 +279,79 @@ func (sc StratifiedCompute) DetermineValidLocationsQuickly(iomanager cc.IOManage
 	fmt.Println(string(stormcenterbytes))
 	return computeResult, nil
 }
+
+// DetermineStormTypeNormalDensityKernelLocations samples the kernel of each storm type around the storm centers of the storms of that type
+// (by the storm type parsed from the storm name) and writes the locations inside the storm type's transposition domain to <type>.csv.
+// Rejected locations are resampled so each storm keeps count locations and each type has at least min_count, the counts of each type
+// are written to storm_type_counts.csv.
 func (sc StratifiedCompute) DetermineStormTypeNormalDensityKernelLocations(iomanager cc.IOManager) error {
 	outputDataSource, err := iomanager.GetOutputDataSource("Locations")
 	if err != nil {
 		return errors.New("could not put locations for this payload")
 	}
 	validlocationsroot := outputDataSource.Paths["default"]
-	layer := sc.TranspositionPolygon.LayerByIndex(0)
-	polygon := layer.Feature(1)
 
-	radius := iomanager.Attributes.GetFloatOrFail("radius")              //50000 //50km
-	alpha := iomanager.Attributes.GetFloatOrFail("alpha")                //.05    //.05,.95 ci
-	count := iomanager.Attributes.GetIntOrFail("count")                  //50
-	seed := iomanager.Attributes.GetIntOrFail("seed")                    //1234
-	stormTypes, err := iomanager.Attributes.GetStringSlice("stormTypes") //[]string{"ST1", "ST2", "ST3", "ST4", "ST5"}
+	count := iomanager.Attributes.GetIntOrFail("count")                 //50
+	seed := iomanager.Attributes.GetIntOrFail("seed")                   //1234
+	minimum := iomanager.Attributes.GetIntOrDefault("min_count", count) //at least one storm's worth of locations per type.
+	centers, catalogTypes := stormCentersByType(sc.GridFile.Events)
+	stormTypes := catalogTypes
+	if _, ok := iomanager.Attributes["stormTypes"]; ok {
+		stormTypes, err = iomanager.Attributes.GetStringSlice("stormTypes") //[]string{"ST1", "ST2", "ST3", "ST4", "ST5"}
+		if err != nil {
+			return err
+		}
+	}
+	kernels, err := DensityKernelsFromAttributes(iomanager.Attributes, stormTypes) //radius 50000 (50km) and alpha .05 (95% confidence) or anisotropic kernels by storm type.
 	if err != nil {
 		return err
 	}
 	rng := rand.New(rand.NewSource(int64(seed)))
 
+	counts := "storm_type,storms,draws,locations\r\n"
 	for _, st := range stormTypes {
-		originalCoordinates := make([]utils.Coordinate, len(sc.GridFile.Events))
-		for i, e := range sc.GridFile.Events {
-			if strings.Contains(e.Name, st) {
-				originalCoordinates[i] = utils.Coordinate{X: e.CenterX, Y: e.CenterY}
-			}
+		transpositionDomain, err := sc.TranspositionDomains.ForStormType(st)
+		if err != nil {
+			return err
 		}
-		masterList := make([]utils.Coordinate, 0)
-		for _, input := range originalCoordinates {
-			output := utils.CreateDensityList(input, alpha, float64(radius), count, rng.Int63())
-			//clip?
-			masterList = append(masterList, output.Coordinates...)
+		clip := func(list utils.CoordinateList) utils.CoordinateList {
+			return utils.ClipDensityList(list, transpositionDomain)
 		}
-
-		fishnet := utils.ClipDensityList(utils.CoordinateList{Coordinates: masterList}, polygon)
+		result, err := kernels[st].SampleDensityLocations(centers[strings.ToLower(st)], count, minimum, rng.Int63(), clip)
+		if err != nil {
+			return fmt.Errorf("could not sample locations for storm type %v: %v", st, err)
+		}
+		sc.logInfo(fmt.Sprintf("storm type %v: %v storms, %v draws, %v locations", st, result.Storms, result.Draws, len(result.Locations.Coordinates)))
+		counts += fmt.Sprintf("%v,%v,%v,%v\r\n", st, result.Storms, result.Draws, len(result.Locations.Coordinates))
 
 		outputDataSource.Paths["default"] = fmt.Sprintf("%v/%v.csv", validlocationsroot, st)
-		err = utils.PutFile(fishnet.ToBytes(), iomanager, outputDataSource, "default")
+		err = utils.PutFile(result.Locations.ToBytes(), iomanager, outputDataSource, "default")
 		if err != nil {
 			return err
 		}
-		//return err
 	}
-	return err
+	outputDataSource.Paths["default"] = fmt.Sprintf("%v/%v.csv", validlocationsroot, "storm_type_counts")
+	return utils.PutFile([]byte(counts), iomanager, outputDataSource, "default")
+}
+
+// stormCentersByType groups the storm centers of the events that have one by lower case storm type, and returns the storm types in the
+// catalog sorted.
+func stormCentersByType(events []hms.PrecipGridEvent) (map[string][]utils.Coordinate, []string) {
+	centers := make(map[string][]utils.Coordinate)
+	types := make([]string, 0)
+	for _, e := range events {
+		st := e.StormType()
+		if st == "" || !e.HasStormCenter() {
+			continue
+		}
+		key := strings.ToLower(st)
+		if _, ok := centers[key]; !ok {
+			types = append(types, st)
+		}
+		centers[key] = append(centers[key], utils.Coordinate{X: e.CenterX, Y: e.CenterY})
+	}
+	sort.Strings(types)
+	return centers, types
 }
 func (sc StratifiedCompute) DetermineNormalDensityKernelLocations(iomanager cc.IOManager) error {
 	outputDataSource, err := iomanager.GetOutputDataSource("Locations")
@@ -350,8 +359,6 @@ func (sc StratifiedCompute) DetermineNormalDensityKernelLocations(iomanager cc.I
 		return errors.New("could not put locations for this payload")
 	}
 	validlocationsroot := outputDataSource.Paths["default"]
-	layer := sc.TranspositionPolygon.LayerByIndex(0)
-	polygon := layer.Feature(1)
 
 	radius := iomanager.Attributes.GetFloatOrFail("radius") //50000 //50km
 	alpha := iomanager.Attributes.GetFloatOrFail("alpha")   //.05    //.05,.95 ci
@@ -370,71 +377,63 @@ func (sc StratifiedCompute) DetermineNormalDensityKernelLocations(iomanager cc.I
 		masterList = append(masterList, output.Coordinates...)
 	}
 
-	fishnet := utils.ClipDensityList(utils.CoordinateList{Coordinates: masterList}, polygon)
+	fishnet := utils.ClipDensityList(utils.CoordinateList{Coordinates: masterList}, sc.TranspositionDomains.Default)
 
 	outputDataSource.Paths["default"] = fmt.Sprintf("%v/%v.csv", validlocationsroot, "all_normal_scramble")
 	err = utils.PutFile(fishnet.ToBytes(), iomanager, outputDataSource, "default")
 	return err
 }
 
+// generateStormCenters generates the candidate locations over every selected feature of the transposition polygon.
 func (sc StratifiedCompute) generateStormCenters() (utils.CoordinateList, error) {
-	return generateUniformPointList(sc.TranspositionPolygon, sc.Spacing)
+	centers, err := generateUniformPointList(sc.TranspositionDomains.Default, sc.Spacing, sc.Pattern, sc.Seed)
+	if err != nil {
+		return centers, err
+	}
+	sc.logInfo(fmt.Sprintf("determined %v %v potential placements", len(centers.Coordinates), sc.Pattern))
+	return centers, nil
+}
 
+// logInfo logs the message if the compute has a logger.
+func (sc StratifiedCompute) logInfo(message string) {
+	if sc.Logger != nil {
+		sc.Logger.Info(message)
+	}
+}
+
+// stormCandidates returns the transposition domain of the storm's type and the candidates inside that domain. The candidates are generated
+// once over every selected feature of the transposition polygon, so they are returned as is unless domains are keyed by storm type, then
+// they are clipped to the storm type's domain (a clipped list keeps the fishnet alignment between storms of different types).
+func (sc StratifiedCompute) stormCandidates(candidates utils.CoordinateList, storm hms.PrecipGridEvent) (utils.Domain, utils.CoordinateList, error) {
+	domain, err := sc.TranspositionDomains.ForStormType(storm.StormType())
+	if err != nil {
+		return domain, candidates, fmt.Errorf("could not find the transposition domain for %v: %v", storm.Name, err)
+	}
+	if len(sc.TranspositionDomains.StormTypes) == 0 {
+		return domain, candidates, nil
+	}
+	return domain, utils.ClipDensityList(candidates, domain), nil
 }
-func generateUniformPointList(ds gdal.DataSource, spacing float64) (utils.CoordinateList, error) {
+
+// generateUniformPointList generates candidate locations over the envelope of the domain with the fishnet pattern and keeps the locations inside the domain.
+func generateUniformPointList(domain utils.Domain, spacing float64, pattern utils.FishnetPattern, seed int64) (utils.CoordinateList, error) {
 	fmt.Printf("determining potential placements\n")
 	coordinates := utils.CoordinateList{Coordinates: make([]utils.Coordinate, 0)}
-	layer := ds.LayerByIndex(0)
-	ref := layer.SpatialReference()
-	//fmt.Println("features:")
-	//fmt.Println(layer.FeatureCount(true))
-	polygon := layer.Feature(1)
-	//defer polygon.Destroy()
-	envelope, err := layer.Extent(true)
+	candidates, err := utils.FishnetCandidates(pattern, domain.Envelope, spacing, seed)
 	if err != nil {
 		return coordinates, err
 	}
-	MaxX := envelope.MaxX()
-	MinX := envelope.MinX()
-	MinY := envelope.MinY()
-	MaxY := envelope.MaxY()
-	y := 0
-	x := 0
-	//get distance in x domain
-	xdist := MaxX - MinX
-
-	//get distance in y domain
-	ydist := MaxY - MinY
-	//get total number of x and y steps
-	xSteps := int(math.Floor(math.Abs(xdist) / spacing))
-	ySteps := int(math.Floor(math.Abs(ydist) / spacing))
-	//offset by half in each direction
-	currentYval := MaxY + (spacing / 2)
-	var currentXval float64
-	//generate a full row, incriment y and start the next row.
-	//fmt.Printf("x,y\n")
-	for y < ySteps { //iterate across all rows
-		x = 0
-		currentXval = MinX + (spacing / 2)
-		for x < xSteps { // Iterate across all x values in a row
-			x++
-			currentXval += spacing
-			//determine if polygon contains the point.
-			location, err := gdal.CreateFromWKT(fmt.Sprintf("Point (%v %v)\n", currentXval, currentYval), ref)
-			if err != nil {
-				return coordinates, err
-			}
-			if polygon.Geometry().Contains(location) {
-				//record the location.
-				//fmt.Printf("%v,%v\n", location.X(0), location.Y(0))
-				coordinates.Coordinates = append(coordinates.Coordinates, utils.Coordinate{X: currentXval, Y: currentYval})
-			}
+	for _, c := range candidates {
+		//determine if the domain contains the point, points in holes are excluded.
+		contained, err := domain.ContainsPoint(c)
+		if err != nil {
+			return coordinates, err
+		}
+		if contained {
+			coordinates.Coordinates = append(coordinates.Coordinates, c)
 		}
-		y++ //step to next row
-		currentYval -= spacing
 	}
-	fmt.Printf("determined %v potential placements\n", len(coordinates.Coordinates))
-	return coordinates, err
+	return coordinates, nil
 }
 func wrieStormCenters(coordinates utils.CoordinateList) error {
 	//write out coordinates.
@@ -446,11 +445,10 @@ func (sc StratifiedCompute) generateGridFiles() (map[string][]byte, error) {
 	outputMap := make(map[string][]byte, 0)
 	//trim root to remove
 	for _, pe := range gf.Events {
-		for _, te := range gf.Temps {
-			if strings.Contains(pe.Name, te.Name) {
-				b := gf.ToBytes(pe, te)
-				outputMap[fmt.Sprintf("%vGridFile.grid", pe.Name)] = b
-			}
+		te := gf.PairedTemperature(pe)
+		if te.Name != "" {
+			b := gf.ToBytes(pe, te)
+			outputMap[fmt.Sprintf("%vGridFile.grid", pe.Name)] = b
 		}
 	}
 	return outputMap, nil
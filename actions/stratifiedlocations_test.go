@@ -133,3 +133,27 @@ func TestValidStratifiedLocations(t *testing.T) {
 	utils.WriteLocalBytes(outbytes, root, fp2)
 	utils.WriteLocalBytes(validoutbytes, root, fp3)
 }
+
+func TestStormCentersByType(t *testing.T) {
+	centered := func(name string, x float64, y float64) hms.PrecipGridEvent {
+		lines := []string{fmt.Sprintf("%v%f", hms.GridStormCenterXKeyword, x), fmt.Sprintf("%v%f", hms.GridStormCenterYKeyword, y)}
+		return hms.PrecipGridEvent{Name: name, CenterX: x, CenterY: y, Lines: lines}
+	}
+	events := []hms.PrecipGridEvent{
+		centered("19790101_72hr_ST1_r01", 1, 1),
+		centered("19790102_72hr_ST10_r01", 10, 10),
+		centered("19790103_72hr_st1_r02", 2, 2),
+		{Name: "19790104_72hr_ST1_r03"}, //no storm center.
+		centered("AORC 1979-01-05", 5, 5),
+	}
+	centers, types := stormCentersByType(events)
+	if len(types) != 2 || types[0] != "ST1" || types[1] != "ST10" {
+		t.Errorf("expected the storm types ST1 and ST10 got %v", types)
+	}
+	if len(centers["st1"]) != 2 || centers["st1"][1] != (utils.Coordinate{X: 2, Y: 2}) {
+		t.Errorf("expected the two centered ST1 storms got %v", centers["st1"])
+	}
+	if len(centers["st10"]) != 1 || len(centers) != 2 {
+		t.Errorf("expected ST10 to be grouped separately from ST1 got %v", centers)
+	}
+}
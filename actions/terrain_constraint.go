@@ -0,0 +1,52 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/dewberry/gdal"
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
+)
+
+// TerrainInputsRequired reports if the Elevation and Barriers inputs are needed for the terrain constraint of the action.
+func TerrainInputsRequired(attributes cc.PayloadAttributes) (bool, bool) {
+	return attributes.GetFloatOrDefault("max_elevation_difference", 0) > 0, attributes.GetBooleanOrDefault("terrain_barriers", false)
+}
+
+// TerrainConstraintFromAttributes reads the elevation raster and barrier geopackage required by the max_elevation_difference and
+// terrain_barriers attributes, placements are not constrained by terrain if neither is provided.
+func TerrainConstraintFromAttributes(attributes cc.PayloadAttributes, elevationBytes []byte, barrierBytes []byte) (utils.TerrainConstraint, error) {
+	tc := utils.TerrainConstraint{}
+	useElevation, useBarriers := TerrainInputsRequired(attributes)
+	if useElevation {
+		filePath := fmt.Sprintf("%v%v", LOCALDIR, "elevation.tif")
+		err := utils.WriteLocalBytes(elevationBytes, LOCALDIR, filePath)
+		if err != nil {
+			return tc, err
+		}
+		tc.Elevation, err = utils.ReadElevationRaster(filePath)
+		if err != nil {
+			return tc, fmt.Errorf("could not read the elevation raster: %v", err)
+		}
+		tc.HasElevation = true
+		tc.MaxElevationDifference = attributes.GetFloatOrFail("max_elevation_difference")
+	}
+	if useBarriers {
+		filePath := fmt.Sprintf("%v%v", LOCALDIR, "barriers.gpkg")
+		err := utils.WriteLocalBytes(barrierBytes, LOCALDIR, filePath)
+		if err != nil {
+			return tc, err
+		}
+		ds := gdal.OpenDataSource(filePath, 0)
+		defer ds.Destroy()
+		selection := utils.DomainSelection{
+			Layer:  attributes.GetStringOrDefault("barrier_layer", ""),
+			Filter: attributes.GetStringOrDefault("barrier_filter", ""),
+		}
+		tc.Barriers, err = utils.ReadBarriers(ds, selection)
+		if err != nil {
+			return tc, fmt.Errorf("could not read the barriers: %v", err)
+		}
+	}
+	return tc, nil
+}
@@ -0,0 +1,90 @@
+package hms
+
+import (
+	"fmt"
+	"strings"
+)
+
+var BasinElementEndKeyword string = "End:"
+var BasinParameterIndent string = "     "
+
+// Basin is an hms basin file, elements are blocks that start with "Type: Name" and end with "End:".
+type Basin struct {
+	Lines []string
+}
+
+func ReadBasin(basinResource []byte) (Basin, error) {
+	basinstring := strings.ReplaceAll(string(basinResource), "\r\n", "\n")
+	lines := strings.Split(basinstring, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return Basin{}, fmt.Errorf("the basin file is empty")
+	}
+	return Basin{Lines: lines}, nil
+}
+
+// Copy returns a basin that can be updated without modifying the original.
+func (b Basin) Copy() Basin {
+	lines := make([]string, len(b.Lines))
+	copy(lines, b.Lines)
+	return Basin{Lines: lines}
+}
+
+// elementRange finds the header and end line indexes of an element by name.
+func (b Basin) elementRange(element string) (int, int, error) {
+	start := -1
+	for idx, l := range b.Lines {
+		if start == -1 {
+			if strings.HasPrefix(l, " ") || !strings.Contains(l, ": ") {
+				continue
+			}
+			if strings.SplitN(l, ": ", 2)[1] == element {
+				start = idx
+			}
+		} else if strings.HasPrefix(l, BasinElementEndKeyword) {
+			return start, idx, nil
+		}
+	}
+	return -1, -1, fmt.Errorf("could not find element %v in the basin file", element)
+}
+
+// ElementParameter returns the value of a parameter of an element.
+func (b Basin) ElementParameter(element string, parameter string) (string, error) {
+	start, end, err := b.elementRange(element)
+	if err != nil {
+		return "", err
+	}
+	prefix := fmt.Sprintf("%v%v: ", BasinParameterIndent, parameter)
+	for _, l := range b.Lines[start:end] {
+		if strings.HasPrefix(l, prefix) {
+			return strings.TrimPrefix(l, prefix), nil
+		}
+	}
+	return "", fmt.Errorf("could not find parameter %v for element %v", parameter, element)
+}
+
+// UpdateElementParameter sets the value of an existing parameter of an element, for example the initial deficit of a subbasin.
+func (b *Basin) UpdateElementParameter(element string, parameter string, value string) error {
+	start, end, err := b.elementRange(element)
+	if err != nil {
+		return err
+	}
+	prefix := fmt.Sprintf("%v%v: ", BasinParameterIndent, parameter)
+	for idx := start; idx < end; idx++ {
+		if strings.HasPrefix(b.Lines[idx], prefix) {
+			b.Lines[idx] = fmt.Sprintf("%v%v", prefix, value)
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find parameter %v for element %v", parameter, element)
+}
+func (b Basin) ToBytes() []byte {
+	out := make([]byte, 0)
+	for _, l := range b.Lines {
+		out = append(out, l...)
+		out = append(out, "\r\n"...)
+	}
+	return out
+}
@@ -2,6 +2,7 @@ package hms
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -9,12 +10,40 @@ import (
 var ControlKeyword string = "Control: "
 var StartDateKeyword string = "     Start Date: " //DD FULLMONTHNAME YYYY
 var StartTimeKeyword string = "     Start Time: " //HH:MM hours in 24 hour clock
+var EndDateKeyword string = "     End Date: "     //DD FULLMONTHNAME YYYY
+var EndTimeKeyword string = "     End Time: "     //HH:MM hours in 24 hour clock
+var TimeIntervalKeyword string = "     Time Interval: "
+var TimeZoneIDKeyword string = "     Time Zone ID: "
+var TimeZoneOffsetKeyword string = "     Time Zone GMT Offset: " //milliseconds
+var ControlEndKeyword string = "End:"
+var DefaultTimeInterval int = 60 //minutes
+
+const controlDateFormat string = "2 January 2006"
+const controlTimeFormat string = "15:04"
 
 type Control struct {
-	Name      string
-	StartDate string
-	StartTime string
-	bytes     []byte
+	Name              string
+	StartDate         string
+	StartTime         string
+	EndDate           string
+	EndTime           string
+	TimeInterval      int //minutes
+	TimeZoneID        string
+	TimeZoneGMTOffset int64 //milliseconds
+	bytes             []byte
+}
+
+// NewControl creates a control specification for a simulation window, the time zone is optional.
+func NewControl(name string, start time.Time, end time.Time, timeInterval int, timeZoneID string) (Control, error) {
+	lines := []string{fmt.Sprint(ControlKeyword, name), ControlEndKeyword, ""}
+	c := Control{Name: name, TimeInterval: timeInterval, bytes: []byte(strings.Join(lines, "\r\n"))}
+	if timeZoneID != "" {
+		err := c.SetTimeZone(timeZoneID)
+		if err != nil {
+			return c, err
+		}
+	}
+	return c, c.SetWindow(start, end)
 }
 
 func ReadControl(controlRI []byte) (Control, error) {
@@ -34,6 +63,29 @@ func ReadControl(controlRI []byte) (Control, error) {
 			control.StartTime = strings.TrimLeft(l, StartTimeKeyword)
 
 		}
+		if strings.HasPrefix(l, EndDateKeyword) {
+			control.EndDate = strings.TrimPrefix(l, EndDateKeyword)
+		}
+		if strings.HasPrefix(l, EndTimeKeyword) {
+			control.EndTime = strings.TrimPrefix(l, EndTimeKeyword)
+		}
+		if strings.HasPrefix(l, TimeIntervalKeyword) {
+			interval, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(l, TimeIntervalKeyword)))
+			if err != nil {
+				return Control{}, fmt.Errorf("could not parse the time interval from %v", l)
+			}
+			control.TimeInterval = interval
+		}
+		if strings.HasPrefix(l, TimeZoneIDKeyword) {
+			control.TimeZoneID = strings.TrimPrefix(l, TimeZoneIDKeyword)
+		}
+		if strings.HasPrefix(l, TimeZoneOffsetKeyword) {
+			offset, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(l, TimeZoneOffsetKeyword)), 10, 64)
+			if err != nil {
+				return Control{}, fmt.Errorf("could not parse the time zone offset from %v", l)
+			}
+			control.TimeZoneGMTOffset = offset
+		}
 	}
 	if control.StartTime == "24:00" {
 		fulltime := fmt.Sprint(control.StartDate, " 00:00")
@@ -46,6 +98,18 @@ func ReadControl(controlRI []byte) (Control, error) {
 		control.StartTime = csdt.Format("15:04") //fmt.Sprintf("%v:%v",csdt.Hour(),csdt.Minute())
 		control.StartDate = csdt.Format("2 January 2006")
 	}
+	if control.EndTime == "24:00" {
+		cedt, err := time.Parse("2 January 2006 15:04", fmt.Sprint(control.EndDate, " 00:00"))
+		if err != nil {
+			return Control{}, err
+		}
+		cedt = cedt.Add(time.Hour * 24)
+		control.EndTime = cedt.Format(controlTimeFormat)
+		control.EndDate = cedt.Format(controlDateFormat)
+	}
+	if control.TimeInterval == 0 {
+		control.TimeInterval = DefaultTimeInterval
+	}
 	control.bytes = controlRI
 	return control, nil
 }
@@ -76,6 +140,84 @@ func (c *Control) AddHoursToStart(timeWindowModifier int) (time.Time, error) {
 	//fmt.Println(c)
 	return csdt, nil
 }
+
+// EndDateAndTime parses the end of the simulation window.
+func (c *Control) EndDateAndTime() (time.Time, error) {
+	return time.Parse("2 January 2006 15:04", fmt.Sprint(c.EndDate, " ", c.EndTime))
+}
+
+// SetWindow sets the start and end of the simulation window, the window must be a positive multiple of the time interval.
+func (c *Control) SetWindow(start time.Time, end time.Time) error {
+	if c.TimeInterval <= 0 {
+		return fmt.Errorf("control %v has an invalid time interval %v", c.Name, c.TimeInterval)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("control %v end %v must be after the start %v", c.Name, end.Format(time.DateTime), start.Format(time.DateTime))
+	}
+	if end.Sub(start)%(time.Duration(c.TimeInterval)*time.Minute) != 0 {
+		return fmt.Errorf("control %v window from %v to %v is not a multiple of the %v minute time interval", c.Name, start.Format(time.DateTime), end.Format(time.DateTime), c.TimeInterval)
+	}
+	c.StartDate = start.Format(controlDateFormat)
+	c.StartTime = start.Format(controlTimeFormat)
+	c.EndDate = end.Format(controlDateFormat)
+	c.EndTime = end.Format(controlTimeFormat)
+	if c.TimeZoneID != "" {
+		return c.SetTimeZone(c.TimeZoneID) //the gmt offset depends on the start for time zones with daylight savings.
+	}
+	return nil
+}
+
+// ShiftWindow moves the start and end of the simulation window by hours, the duration is preserved.
+func (c *Control) ShiftWindow(hours int) (time.Time, error) {
+	start, err := c.StartDateAndTime()
+	if err != nil {
+		return start, err
+	}
+	end, err := c.EndDateAndTime()
+	if err != nil {
+		return start, err
+	}
+	shift := time.Duration(hours) * time.Hour
+	start = start.Add(shift)
+	return start, c.SetWindow(start, end.Add(shift))
+}
+
+// WindowAroundStorm builds the simulation window around a storm, the start is the storm start less the warm up period and the end is the
+// storm start plus the storm duration and the recession period. The start is floored and the end is ceiled to the time interval.
+func (c *Control) WindowAroundStorm(stormStart time.Time, warmUp time.Duration, stormDuration time.Duration, recession time.Duration) error {
+	if c.TimeInterval <= 0 {
+		c.TimeInterval = DefaultTimeInterval
+	}
+	if warmUp < 0 || stormDuration <= 0 || recession < 0 {
+		return fmt.Errorf("control %v requires a positive storm duration and non negative warm up and recession periods", c.Name)
+	}
+	interval := time.Duration(c.TimeInterval) * time.Minute
+	start := stormStart.Add(-warmUp).Truncate(interval)
+	end := stormStart.Add(stormDuration + recession)
+	if end.Truncate(interval) != end {
+		end = end.Truncate(interval).Add(interval)
+	}
+	return c.SetWindow(start, end)
+}
+
+// SetTimeZone sets the time zone id and the gmt offset at the start of the simulation window.
+func (c *Control) SetTimeZone(timeZoneID string) error {
+	loc, err := time.LoadLocation(timeZoneID)
+	if err != nil {
+		return err
+	}
+	reference := time.Now()
+	if c.StartDate != "" {
+		start, err := c.StartDateAndTime()
+		if err == nil {
+			reference = start
+		}
+	}
+	_, offset := time.Date(reference.Year(), reference.Month(), reference.Day(), reference.Hour(), reference.Minute(), 0, 0, loc).Zone()
+	c.TimeZoneID = timeZoneID
+	c.TimeZoneGMTOffset = int64(offset) * 1000
+	return nil
+}
 func (c Control) ComputeOffset(gridStartDateTime string) int {
 	//parse input as DDMMMYYYY:HHMM //24 hour clocktime
 	//	Jan 2 15:04:05 2006 MST - reference
@@ -99,14 +241,36 @@ func (c Control) ComputeOffset(gridStartDateTime string) int {
 func (c Control) ToBytes() []byte {
 	controlstring := string(c.bytes)
 	inlines := strings.Split(controlstring, "\r\n") //maybe rn?
+	//the window, interval, and time zone are written together, lines missing from the original file are added before the end of the control block.
+	values := []struct {
+		keyword string
+		value   string
+	}{
+		{TimeZoneIDKeyword, c.TimeZoneID},
+		{TimeZoneOffsetKeyword, fmt.Sprint(c.TimeZoneGMTOffset)},
+		{StartDateKeyword, c.StartDate},
+		{StartTimeKeyword, c.StartTime},
+		{EndDateKeyword, c.EndDate},
+		{EndTimeKeyword, c.EndTime},
+		{TimeIntervalKeyword, fmt.Sprint(c.TimeInterval)},
+	}
+	written := make([]bool, len(values))
 	outlines := ""
 	for _, l := range inlines {
 		line := l
-		if strings.Contains(l, StartDateKeyword) {
-			line = fmt.Sprintf("%v%v", StartDateKeyword, c.StartDate)
+		if l == ControlEndKeyword {
+			for i, v := range values {
+				if !written[i] && v.value != "" && !(v.keyword == TimeZoneOffsetKeyword && c.TimeZoneID == "") {
+					outlines = fmt.Sprint(outlines, v.keyword, v.value, "\r\n")
+					written[i] = true
+				}
+			}
 		}
-		if strings.Contains(l, StartTimeKeyword) {
-			line = fmt.Sprintf("%v%v", StartTimeKeyword, c.StartTime)
+		for i, v := range values {
+			if strings.Contains(l, v.keyword) {
+				line = fmt.Sprintf("%v%v", v.keyword, v.value)
+				written[i] = true
+			}
 		}
 		outlines = fmt.Sprint(outlines, line, "\r\n")
 	}
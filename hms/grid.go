@@ -3,7 +3,10 @@ package hms
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -18,6 +21,27 @@ var DssPathNameKeyword string = "       DSS Pathname: "
 var DssFileNameKeyword string = "       DSS File Name: "
 var GridStormCenterXKeyword string = "     Storm Center X: "
 var GridStormCenterYKeyword string = "     Storm Center Y: "
+var TemperatureDssParameter string = "TEMPERATURE"
+var TemperatureReferenceHeightLines []string = []string{"     Reference Height Units: Meters", "     Reference Height: 10.0"}
+
+// CompanionGridType describes a grid type that is transposed with precipitation and the met model method block that references it.
+type CompanionGridType struct {
+	GridType           string //the grid type in the grid manager.
+	MetStartKeyword    string //the met model method parameters block.
+	MetGridNameKeyword string //the grid name line in the method parameters block.
+}
+
+// CompanionGridTypes are the companion grid types that can be configured by name.
+var CompanionGridTypes map[string]CompanionGridType = map[string]CompanionGridType{
+	"swe":                 {GridType: "Snow Water Equivalent", MetStartKeyword: "Snowmelt Method Parameters:", MetGridNameKeyword: "     SWE Grid Name: "},
+	"cold_content":        {GridType: "Cold Content", MetStartKeyword: "Snowmelt Method Parameters:", MetGridNameKeyword: "     Cold Content Grid Name: "},
+	"wind":                {GridType: "Windspeed", MetStartKeyword: "Windspeed Method Parameters:", MetGridNameKeyword: "     Windspeed Grid Name: "},
+	"shortwave_radiation": {GridType: "Shortwave Radiation", MetStartKeyword: "Shortwave Radiation Method Parameters:", MetGridNameKeyword: "     Shortwave Radiation Grid Name: "},
+	"longwave_radiation":  {GridType: "Longwave Radiation", MetStartKeyword: "Longwave Radiation Method Parameters:", MetGridNameKeyword: "     Longwave Radiation Grid Name: "},
+}
+
+// gridDatePattern finds a storm date (yyyymmdd or yyyy-mm-dd) in a grid name.
+var gridDatePattern = regexp.MustCompile(`(\d{4})-?(\d{2})-?(\d{2})`)
 
 type PrecipGridEvent struct {
 	Name      string
@@ -27,19 +51,38 @@ type PrecipGridEvent struct {
 	Lines     []string
 }
 type TempGridEvent struct {
-	Name  string
-	Lines []string
+	Name      string
+	StartTime string //parse DDMMMYYYY:HHMM //24 hour clocktime
+	Lines     []string
+}
+
+// CompanionGridEvent is a grid of a companion grid type (see CompanionGridTypes) that is transposed with a precipitation grid.
+type CompanionGridEvent struct {
+	Type      string
+	Name      string
+	StartTime string //parse DDMMMYYYY:HHMM //24 hour clocktime
+	Lines     []string
 }
 type GridFileInfo struct {
 	Lines []string
 }
 type GridFile struct {
 	GridFileInfo
-	Events []PrecipGridEvent
-	Temps  []TempGridEvent
+	Events         []PrecipGridEvent
+	Temps          []TempGridEvent
+	CompanionTypes []string
+	Companions     []CompanionGridEvent
 }
 
 func ReadGrid(gridResource []byte) (GridFile, error) {
+	return readGrid(gridResource, true)
+}
+
+// ReadGridWithoutCenters reads all precipitation grids including grids without storm centers, it is used to derive storm centers for a catalog.
+func ReadGridWithoutCenters(gridResource []byte) (GridFile, error) {
+	return readGrid(gridResource, false)
+}
+func readGrid(gridResource []byte, requireStormCenters bool) (GridFile, error) {
 	//read bytes
 	//loop through and find grids
 	gridstring := string(gridResource)
@@ -66,7 +109,7 @@ func ReadGrid(gridResource []byte) (GridFile, error) {
 			gridFound = true
 			precipGridLines = make([]string, 0)
 			tempGridLines = make([]string, 0)
-			name := strings.TrimLeft(l, GridStartKeyword)
+			name := strings.TrimPrefix(l, GridStartKeyword)
 			//wont know it is precip for one more line...
 			//so get the name just in case.
 			//add the first line just in case.
@@ -78,7 +121,7 @@ func ReadGrid(gridResource []byte) (GridFile, error) {
 			tempGridLines = append(tempGridLines, l)
 		}
 		if strings.Contains(l, GridTypeKeyword) {
-			gridType := strings.TrimLeft(l, GridTypeKeyword)
+			gridType := strings.TrimPrefix(l, GridTypeKeyword)
 			if gridType == PrecipitationKeyword {
 				isPrecipGrid = true
 				foundX = false
@@ -95,7 +138,7 @@ func ReadGrid(gridResource []byte) (GridFile, error) {
 		if gridFound {
 			if isPrecipGrid {
 				if strings.Contains(l, GridStormCenterXKeyword) {
-					centerxstring := strings.TrimLeft(l, GridStormCenterXKeyword)
+					centerxstring := strings.TrimPrefix(l, GridStormCenterXKeyword)
 					x, err := strconv.ParseFloat(centerxstring, 64)
 					if err != nil {
 						foundX = false
@@ -105,7 +148,7 @@ func ReadGrid(gridResource []byte) (GridFile, error) {
 					}
 				}
 				if strings.Contains(l, GridStormCenterYKeyword) {
-					centerystring := strings.TrimLeft(l, GridStormCenterYKeyword)
+					centerystring := strings.TrimPrefix(l, GridStormCenterYKeyword)
 					y, err := strconv.ParseFloat(centerystring, 64)
 					if err != nil {
 						foundY = false
@@ -129,7 +172,7 @@ func ReadGrid(gridResource []byte) (GridFile, error) {
 			if gridFound {
 				gridFound = false
 				if isPrecipGrid {
-					if foundX && foundY {
+					if (foundX && foundY) || !requireStormCenters {
 						precipGrid.Lines = precipGridLines
 						precipgrids = append(precipgrids, precipGrid)
 					} else {
@@ -151,35 +194,128 @@ func ReadGrid(gridResource []byte) (GridFile, error) {
 		}
 
 		if strings.Contains(l, DssPathNameKeyword) {
-			pathName := strings.TrimLeft(l, DssPathNameKeyword)
-			parts := strings.Split(pathName, "/")
-			startTime := parts[4] //parse DDMMMYYYY:HHMM //24 hour clocktime
-			precipGrid.StartTime = startTime
-			if strings.Contains(precipGrid.StartTime, "2400") {
-				precipGrid.StartTime = strings.Replace(precipGrid.StartTime, "2400", "2359", 1)
+			startTime := dssStartTime(strings.TrimPrefix(l, DssPathNameKeyword))
+			if isTempGrid {
+				tempGrid.StartTime = startTime
+			} else {
+				precipGrid.StartTime = startTime
 			}
 		}
 
 	}
 	gridFileInfo.Lines = gridLines
 	if len(precipgrids) == 0 {
+		if !requireStormCenters {
+			return GridFile{GridFileInfo: gridFileInfo, Events: precipgrids}, errors.New("found no precipitation grids")
+		}
 		return GridFile{GridFileInfo: gridFileInfo, Events: precipgrids}, errors.New("found no grids with x and y centers specified, please specify storm centers for transposition")
 	}
 	return GridFile{GridFileInfo: gridFileInfo, Events: precipgrids, Temps: tempgrids}, nil
 }
-func (gf *GridFile) Bootstrap(knowledgeUncertaintySeed int64, resultingCatalogLength int) error {
-	length := len(gf.Events)
+
+// dssStartTime parses the start time (d part) from a dss pathname, 2400 is replaced with 2359 so it can be parsed.
+func dssStartTime(pathName string) string {
+	parts := strings.Split(pathName, "/")
+	if len(parts) < 5 {
+		return ""
+	}
+	startTime := parts[4] //parse DDMMMYYYY:HHMM //24 hour clocktime
+	if strings.Contains(startTime, "2400") {
+		startTime = strings.Replace(startTime, "2400", "2359", 1)
+	}
+	return startTime
+}
+
+// BootstrapOptions describes how a catalog is resampled.
+type BootstrapOptions struct {
+	Length              int  //the number of events in the resulting catalog.
+	SubsetLength        int  //if greater than 0, a subset of this size is sampled without replacement (from an uber catalog) before bootstrapping.
+	StratifyByStormType bool //if true, each storm type is bootstrapped separately so storm type proportions are preserved.
+}
+
+// Bootstrap returns a new catalog of resultingCatalogLength events sampled with replacement, the grid file is not modified.
+func (gf GridFile) Bootstrap(knowledgeUncertaintySeed int64, resultingCatalogLength int) (GridFile, error) {
+	return gf.BootstrapCatalog(knowledgeUncertaintySeed, BootstrapOptions{Length: resultingCatalogLength})
+}
+
+// BootstrapCatalog returns a new catalog sampled according to the options, temperature grids are carried with the catalog so pairings are preserved.
+func (gf GridFile) BootstrapCatalog(knowledgeUncertaintySeed int64, options BootstrapOptions) (GridFile, error) {
+	if len(gf.Events) == 0 {
+		return gf, errors.New("cannot bootstrap a catalog with no events")
+	}
+	if options.Length < 1 {
+		return gf, fmt.Errorf("cannot bootstrap a catalog to length %v", options.Length)
+	}
+	if options.SubsetLength > len(gf.Events) {
+		return gf, fmt.Errorf("cannot sample a subset of %v events from a catalog of %v events", options.SubsetLength, len(gf.Events))
+	}
 	r := rand.New(rand.NewSource(knowledgeUncertaintySeed))
-	updatedList := make([]PrecipGridEvent, length)
-	for i := 0; i < resultingCatalogLength; i++ {
-		idx := r.Int31n(int32(length))
-		updatedList[i] = gf.Events[idx] //sample with replacement.
-	}
-	gf.Events = updatedList //replace dataset with bootstrap.
-	//for _, e := range updatedList {
-	//	fmt.Println(e.Name)
-	//}
-	return nil
+	source := gf.Events
+	if options.SubsetLength > 0 {
+		source = make([]PrecipGridEvent, options.SubsetLength)
+		for i, idx := range r.Perm(len(gf.Events))[:options.SubsetLength] {
+			source[i] = gf.Events[idx] //sample without replacement.
+		}
+	}
+	updatedList := make([]PrecipGridEvent, 0, options.Length)
+	if options.StratifyByStormType {
+		types, groups := groupByStormType(source)
+		counts := allocateByProportion(options.Length, types, groups, len(source))
+		for _, st := range types {
+			group := groups[st]
+			for i := 0; i < counts[st]; i++ {
+				updatedList = append(updatedList, group[r.Int31n(int32(len(group)))]) //sample with replacement.
+			}
+		}
+	} else {
+		for i := 0; i < options.Length; i++ {
+			idx := r.Int31n(int32(len(source)))
+			updatedList = append(updatedList, source[idx]) //sample with replacement.
+		}
+	}
+	temps := make([]TempGridEvent, len(gf.Temps))
+	copy(temps, gf.Temps)
+	companions := make([]CompanionGridEvent, len(gf.Companions))
+	copy(companions, gf.Companions)
+	return GridFile{GridFileInfo: gf.GridFileInfo, Events: updatedList, Temps: temps, CompanionTypes: gf.CompanionTypes, Companions: companions}, nil
+}
+
+// groupByStormType groups events by storm type, the storm types are returned sorted.
+func groupByStormType(events []PrecipGridEvent) ([]string, map[string][]PrecipGridEvent) {
+	groups := make(map[string][]PrecipGridEvent)
+	types := make([]string, 0)
+	for _, e := range events {
+		st := e.StormType()
+		if _, ok := groups[st]; !ok {
+			types = append(types, st)
+		}
+		groups[st] = append(groups[st], e)
+	}
+	sort.Strings(types)
+	return types, groups
+}
+
+// allocateByProportion splits length across storm types in proportion to their counts using the largest remainder.
+func allocateByProportion(length int, types []string, groups map[string][]PrecipGridEvent, total int) map[string]int {
+	counts := make(map[string]int)
+	remainders := make([]float64, len(types))
+	allocated := 0
+	for i, st := range types {
+		exact := float64(length) * float64(len(groups[st])) / float64(total)
+		counts[st] = int(math.Floor(exact))
+		remainders[i] = exact - math.Floor(exact)
+		allocated += counts[st]
+	}
+	order := make([]int, len(types))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; allocated < length; i++ {
+		counts[types[order[i%len(order)]]]++
+		allocated++
+	}
+	return counts
 }
 func (gf GridFile) SelectEvent(naturalVariabilitySeed int64) (PrecipGridEvent, TempGridEvent, error) {
 	//randomly select one event from the list of events
@@ -187,17 +323,178 @@ func (gf GridFile) SelectEvent(naturalVariabilitySeed int64) (PrecipGridEvent, T
 	r := rand.New(rand.NewSource(naturalVariabilitySeed))
 	idx := r.Int31n(int32(length))
 	pge := gf.Events[idx]
-	for _, tempEvent := range gf.Temps {
-		if strings.Contains(pge.Name, tempEvent.Name) {
-			return pge, tempEvent, nil
+	return pge, gf.PairedTemperature(pge), nil
+}
+
+// PairedTemperature finds the temperature grid for a precipitation grid, a temperature grid with the same name is preferred
+// otherwise grids are paired by storm date. An empty TempGridEvent is returned if the catalog does not have a pair.
+func (gf GridFile) PairedTemperature(pge PrecipGridEvent) TempGridEvent {
+	names := make([]string, len(gf.Temps))
+	for i, tempEvent := range gf.Temps {
+		names[i] = tempEvent.Name
+	}
+	idx := pairedIndex(pge.Name, names)
+	if idx < 0 {
+		return TempGridEvent{}
+	}
+	return gf.Temps[idx]
+}
+
+// PairedCompanions finds a grid of each companion type for a precipitation grid, grids are paired like temperature grids.
+func (gf GridFile) PairedCompanions(pge PrecipGridEvent) ([]CompanionGridEvent, error) {
+	paired := make([]CompanionGridEvent, 0, len(gf.CompanionTypes))
+	for _, companionType := range gf.CompanionTypes {
+		names := make([]string, len(gf.Companions))
+		for i, c := range gf.Companions {
+			if c.Type == companionType {
+				names[i] = c.Name
+			}
+		}
+		idx := pairedIndex(pge.Name, names)
+		if idx < 0 {
+			return paired, fmt.Errorf("could not find a %v grid for %v", companionType, pge.Name)
+		}
+		paired = append(paired, gf.Companions[idx])
+	}
+	return paired, nil
+}
+
+// pairedIndex finds the grid name that pairs with a precipitation grid name, an exact match is preferred over a storm date match.
+// empty names are skipped, -1 is returned if there is not a pair.
+func pairedIndex(precipName string, names []string) int {
+	for i, name := range names {
+		if name != "" && name == precipName {
+			return i
+		}
+	}
+	key := gridPairingKey(precipName)
+	for i, name := range names {
+		if name != "" && gridPairingKey(name) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// ExtractCompanions moves the grids of the companion types (keys of CompanionGridTypes) out of the grid file info so they are only written
+// with the precipitation grid they are paired with, grids of other types are written with every event.
+func (gf GridFile) ExtractCompanions(companionTypes []string) (GridFile, error) {
+	if len(companionTypes) == 0 {
+		return gf, nil
+	}
+	byGridType := make(map[string]string)
+	for _, ct := range companionTypes {
+		definition, ok := CompanionGridTypes[ct]
+		if !ok {
+			return gf, fmt.Errorf("%v is not a companion grid type", ct)
+		}
+		byGridType[definition.GridType] = ct
+	}
+	lines := make([]string, 0, len(gf.GridFileInfo.Lines))
+	companions := make([]CompanionGridEvent, 0)
+	block := make([]string, 0)
+	inGrid := false
+	for _, l := range gf.GridFileInfo.Lines {
+		if strings.HasPrefix(l, GridStartKeyword) {
+			inGrid = true
+			block = []string{l}
+			continue
+		}
+		if !inGrid {
+			lines = append(lines, l)
+			continue
 		}
+		block = append(block, l)
+		if !strings.HasPrefix(l, GridEndKeyword) {
+			continue
+		}
+		inGrid = false
+		companion := CompanionGridEvent{Name: strings.TrimPrefix(block[0], GridStartKeyword), Lines: block}
+		for _, bl := range block {
+			if strings.HasPrefix(bl, GridTypeKeyword) {
+				companion.Type = byGridType[strings.TrimPrefix(bl, GridTypeKeyword)]
+			}
+			if strings.HasPrefix(bl, DssPathNameKeyword) {
+				companion.StartTime = dssStartTime(strings.TrimPrefix(bl, DssPathNameKeyword))
+			}
+		}
+		if companion.Type == "" {
+			lines = append(lines, block...)
+		} else {
+			companions = append(companions, companion)
+		}
+	}
+	if inGrid {
+		lines = append(lines, block...) //an unterminated grid is left as is.
 	}
-	return pge, TempGridEvent{}, nil
+	types := make([]string, len(companionTypes))
+	copy(types, companionTypes)
+	return GridFile{GridFileInfo: GridFileInfo{Lines: lines}, Events: gf.Events, Temps: gf.Temps, CompanionTypes: types, Companions: companions}, nil
+}
+
+// gridPairingKey is the storm date (yyyymmdd) in a grid name, names without a date are their own key.
+func gridPairingKey(name string) string {
+	match := gridDatePattern.FindStringSubmatch(name)
+	if match == nil {
+		return name
+	}
+	return match[1] + match[2] + match[3]
 }
 func (gf GridFile) SelectEventByIndex(idx int64) (PrecipGridEvent, error) {
 	//provide the indexed event
 	return gf.Events[idx-1], nil
 }
+
+// StormType parses the storm type from a grid name following the yyyymmdd_xxhr_storm-type_storm-rank convention, an empty string is returned if the name does not follow the convention.
+func (pge PrecipGridEvent) StormType() string {
+	parts := strings.Split(pge.Name, "_")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+// HasStormCenter is true if the grid has storm center x and y lines.
+func (pge PrecipGridEvent) HasStormCenter() bool {
+	foundX, foundY := false, false
+	for _, l := range pge.Lines {
+		foundX = foundX || strings.HasPrefix(l, GridStormCenterXKeyword)
+		foundY = foundY || strings.HasPrefix(l, GridStormCenterYKeyword)
+	}
+	return foundX && foundY
+}
+
+// UpdateStormCenter sets the storm center, storm center lines that do not exist are added before the end of the grid.
+func (pge *PrecipGridEvent) UpdateStormCenter(x float64, y float64) {
+	pge.CenterX = x
+	pge.CenterY = y
+	xLine := fmt.Sprintf("%v%f", GridStormCenterXKeyword, x)
+	yLine := fmt.Sprintf("%v%f", GridStormCenterYKeyword, y)
+	lines := make([]string, 0, len(pge.Lines)+2)
+	foundX, foundY := false, false
+	for _, l := range pge.Lines {
+		switch {
+		case strings.HasPrefix(l, GridStormCenterXKeyword):
+			foundX = true
+			lines = append(lines, xLine)
+		case strings.HasPrefix(l, GridStormCenterYKeyword):
+			foundY = true
+			lines = append(lines, yLine)
+		case strings.HasPrefix(l, GridEndKeyword):
+			if !foundX {
+				lines = append(lines, xLine)
+			}
+			if !foundY {
+				lines = append(lines, yLine)
+			}
+			foundX, foundY = true, true
+			lines = append(lines, l)
+		default:
+			lines = append(lines, l)
+		}
+	}
+	pge.Lines = lines
+}
 func (pge *PrecipGridEvent) OriginalDSSFile() (string, error) {
 	for _, l := range pge.Lines {
 		if strings.Contains(l, DssFileNameKeyword) {
@@ -217,6 +514,47 @@ func (pge *PrecipGridEvent) UpdateDSSFile(stormName string) error {
 	}
 	return nil
 }
+
+// SynthesizeTemperature builds a temperature grid for a precipitation grid when the catalog does not have one.
+// The temperature grid is named after the precipitation grid with a temperature suffix so the names do not collide in the grid file,
+// and reads the TEMPERATURE records from the same dss file.
+func (pge PrecipGridEvent) SynthesizeTemperature() TempGridEvent {
+	name := fmt.Sprintf("%v %v", pge.Name, strings.ToLower(TemperatureKeyword))
+	lines := make([]string, 0, len(pge.Lines)+len(TemperatureReferenceHeightLines))
+	for _, l := range pge.Lines {
+		switch {
+		case strings.HasPrefix(l, GridStartKeyword):
+			lines = append(lines, GridStartKeyword+name)
+		case strings.HasPrefix(l, GridTypeKeyword):
+			lines = append(lines, GridTypeKeyword+TemperatureKeyword)
+			lines = append(lines, TemperatureReferenceHeightLines...)
+		case strings.HasPrefix(l, GridStormCenterXKeyword), strings.HasPrefix(l, GridStormCenterYKeyword):
+			continue //storm centers only apply to precipitation.
+		case strings.HasPrefix(l, DssPathNameKeyword):
+			parts := strings.Split(strings.TrimPrefix(l, DssPathNameKeyword), "/")
+			if len(parts) > 3 {
+				parts[3] = TemperatureDssParameter
+			}
+			lines = append(lines, DssPathNameKeyword+strings.Join(parts, "/"))
+		default:
+			lines = append(lines, l)
+		}
+	}
+	return TempGridEvent{Name: name, StartTime: pge.StartTime, Lines: lines}
+}
+func (ce *CompanionGridEvent) UpdateDSSFile(stormName string) error {
+	//force the name to be constant in the file. "/data/Storm.dss"
+	path := fmt.Sprintf("data/%v.dss", stormName)
+	lines := make([]string, len(ce.Lines))
+	for idx, l := range ce.Lines {
+		lines[idx] = l
+		if strings.Contains(l, DssFileNameKeyword) {
+			lines[idx] = fmt.Sprintf("%v%v", DssFileNameKeyword, path)
+		}
+	}
+	ce.Lines = lines
+	return nil
+}
 func (pge *TempGridEvent) UpdateDSSFile(stormName string) error {
 	//force the name to be constant in the file. "/data/Storm.dss"
 	path := fmt.Sprintf("data/%v.dss", stormName)
@@ -227,7 +565,39 @@ func (pge *TempGridEvent) UpdateDSSFile(stormName string) error {
 	}
 	return nil
 }
-func (gf GridFile) ToBytes(precipEvent PrecipGridEvent, tempEvent TempGridEvent) []byte {
+
+// CatalogBytes writes the grid file with every grid, it is used to update a catalog rather than to write the grid file for an event.
+func (gf GridFile) CatalogBytes() []byte {
+	b := make([]byte, 0)
+	for _, l := range gf.GridFileInfo.Lines {
+		b = append(b, l...)
+		if l == GridEndKeyword {
+			b = append(b, "\r\n"...)
+		}
+		b = append(b, "\r\n"...)
+	}
+	grids := make([][]string, 0, len(gf.Events)+len(gf.Temps)+len(gf.Companions))
+	for _, e := range gf.Events {
+		grids = append(grids, e.Lines)
+	}
+	for _, t := range gf.Temps {
+		grids = append(grids, t.Lines)
+	}
+	for _, c := range gf.Companions {
+		grids = append(grids, c.Lines)
+	}
+	for _, lines := range grids {
+		for _, l := range lines {
+			b = append(b, l...)
+			b = append(b, "\r\n"...)
+		}
+		b = append(b, "\r\n"...)
+	}
+	return b
+}
+
+// ToBytes writes the grid file for an event, companion grids are written after the precipitation and temperature grids.
+func (gf GridFile) ToBytes(precipEvent PrecipGridEvent, tempEvent TempGridEvent, companions ...CompanionGridEvent) []byte {
 	b := make([]byte, 0)
 	for _, l := range gf.GridFileInfo.Lines {
 		b = append(b, l...)
@@ -241,15 +611,13 @@ func (gf GridFile) ToBytes(precipEvent PrecipGridEvent, tempEvent TempGridEvent)
 		b = append(b, l...)
 		b = append(b, "\r\n"...)
 	}
-	if tempEvent.Name == "" {
-		//gridName := precipEvent.Name
-		//gridNameSansAORC := strings.Trim(gridName, "AORC ")
-		//gridNameSansAORC = strings.ReplaceAll(gridNameSansAORC, " ", "_")
-		//gridNameSansAORC = strings.ReplaceAll(gridNameSansAORC, "-", "")
-		//tempMonkeyPatch := fmt.Sprintf("Grid: %v\n     Grid Type: Temperature\n     Last Modified Date: 9 August 2022\n     Last Modified Time: 17:14:25\n     Reference Height Units: Meters\n     Reference Height: 10.0\n     Data Source Type: External DSS\n     Variant: Variant-1\n       Last Variant Modified Date: 9 August 2022\n       Last Variant Modified Time: 17:14:25\n       Default Variant: Yes\n       DSS File Name: data/Storm.dss\n       DSS Pathname: /SHG2K/KANAWHA/TEMPERATURE///AORC/\n     End Variant: Variant-1\n     Use Lookup Table: No\nEnd:\n", gridName)
-		//b = append(b, tempMonkeyPatch...)
-	} else {
-		for _, l := range tempEvent.Lines {
+	//an empty temperature event means the model does not use gridded temperature, see SynthesizeTemperature for catalogs without one.
+	for _, l := range tempEvent.Lines {
+		b = append(b, l...)
+		b = append(b, "\r\n"...)
+	}
+	for _, c := range companions {
+		for _, l := range c.Lines {
 			b = append(b, l...)
 			b = append(b, "\r\n"...)
 		}
@@ -129,7 +129,7 @@ func TestReadBootstrapSelectUpdateWriteGrid(t *testing.T) {
 	}
 	g, _ := ReadGrid(bytes)
 	rnd := rand.New(rand.NewSource(1234))
-	g.Bootstrap(rnd.Int63(), len(g.Events))
+	g, _ = g.Bootstrap(rnd.Int63(), len(g.Events))
 	for _, pge := range g.Events {
 		fmt.Printf("Event Name: %v \n", pge.Name)
 	}
@@ -148,3 +148,379 @@ func TestTimeSubstitution(t *testing.T) {
 	rounded := math.Round(duration)
 	fmt.Println(rounded)
 }
+
+const testGrid = "Grid Manager: test\r\nEnd:\r\n\r\nGrid: AORC 1979-02-05\r\n     Grid Type: Precipitation\r\n       DSS File Name: data/1979-02-05.dss\r\n       DSS Pathname: /SHG4K/TEST/PRECIPITATION/05FEB1979:0100/05FEB1979:0200/AORC/\r\n     Storm Center X: 100\r\n     Storm Center Y: 200\r\nEnd:\r\n\r\nGrid: AORC 1980-03-06\r\n     Grid Type: Precipitation\r\n       DSS File Name: data/1980-03-06.dss\r\n       DSS Pathname: /SHG4K/TEST/PRECIPITATION/06MAR1980:0100/06MAR1980:0200/AORC/\r\n     Storm Center X: 300\r\n     Storm Center Y: 400\r\nEnd:\r\n"
+
+// stormTypedCatalog builds a catalog with precipitation grids named by the yyyymmdd_xxhr_storm-type_storm-rank convention and one temperature grid per date.
+func stormTypedCatalog(counts map[string]int) GridFile {
+	g := GridFile{}
+	day := 1
+	for _, st := range []string{"ST1", "ST2", "ST3"} {
+		for i := 0; i < counts[st]; i++ {
+			date := fmt.Sprintf("197901%02d", day)
+			g.Events = append(g.Events, PrecipGridEvent{Name: fmt.Sprintf("%v_72hr_%v_r%02d", date, st, i+1)})
+			g.Temps = append(g.Temps, TempGridEvent{Name: date})
+			day++
+		}
+	}
+	return g
+}
+func TestBootstrapCatalog(t *testing.T) {
+	g := stormTypedCatalog(map[string]int{"ST1": 6, "ST2": 3, "ST3": 1})
+	b, err := g.BootstrapCatalog(1234, BootstrapOptions{Length: 20, StratifyByStormType: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Events) != 20 {
+		t.Errorf("expected 20 events got %v", len(b.Events))
+	}
+	counts := make(map[string]int)
+	for _, e := range b.Events {
+		counts[e.StormType()]++
+	}
+	if counts["ST1"] != 12 || counts["ST2"] != 6 || counts["ST3"] != 2 {
+		t.Errorf("expected storm type proportions to be preserved, got %v", counts)
+	}
+	if len(g.Events) != 10 {
+		t.Errorf("bootstrapping modified the original catalog")
+	}
+	b, err = g.BootstrapCatalog(1234, BootstrapOptions{Length: 7, SubsetLength: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Events) != 7 {
+		t.Errorf("expected 7 events got %v", len(b.Events))
+	}
+	unique := make(map[string]bool)
+	for _, e := range b.Events {
+		unique[e.Name] = true
+		temp := b.PairedTemperature(e)
+		if temp.Name == "" || !strings.HasPrefix(e.Name, temp.Name) {
+			t.Errorf("expected %v to be paired with its temperature grid, got %v", e.Name, temp.Name)
+		}
+	}
+	if len(unique) > 4 {
+		t.Errorf("expected at most 4 unique events from the subset got %v", len(unique))
+	}
+	_, err = g.BootstrapCatalog(1234, BootstrapOptions{Length: 5, SubsetLength: 11})
+	if err == nil {
+		t.Error("expected an error for a subset larger than the catalog")
+	}
+}
+func TestReadRealizationsCsv(t *testing.T) {
+	r, err := ReadCsv([]byte("event,realizations\r\n1, 10\r\n20230101_72hr_ST1_r01,25\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := r.Count(1, "")
+	if err != nil || count != 10 {
+		t.Errorf("expected 10 realizations for event 1 got %v %v", count, err)
+	}
+	count, err = r.Count(7, "20230101_72hr_ST1_r01.dss")
+	if err != nil || count != 25 {
+		t.Errorf("expected 25 realizations for the storm got %v %v", count, err)
+	}
+	_, err = r.Count(7, "missing")
+	if err == nil {
+		t.Error("expected an error for an event without a realization count")
+	}
+	for _, bad := range []string{"event,realizations\n1,0\n", "event,realizations\n1,2\n1,3\n", "event,realizations\n1,x\n", "event,realizations\n"} {
+		_, err = ReadCsv([]byte(bad))
+		if err == nil {
+			t.Errorf("expected an error reading %q", bad)
+		}
+	}
+}
+
+const testMca = "Uncertainty: Uncertainty 1\r\n     Description: test\r\n     Seed Value: 1\r\n     Number Of Realizations: 50\r\nEnd:\r\n"
+
+func TestMcaUpdateEvent(t *testing.T) {
+	m, err := ReadMca([]byte(testMca))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.RealizationCount != 50 {
+		t.Errorf("expected 50 realizations got %v", m.RealizationCount)
+	}
+	r := Realizations{Query: map[string]int{"3": 12}}
+	err = m.UpdateEvent(r, 3, "storm", 4321)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(m.ToBytes())
+	if !strings.Contains(s, RealizationsKeyword+"12\r\n") || !strings.Contains(s, SeedKeyword+"4321\r\n") {
+		t.Errorf("expected the seed and realizations to be updated got %v", s)
+	}
+	m, err = ReadMca([]byte(strings.Replace(testMca, "     Number Of Realizations: 50\r\n", "", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.RealizationCount != DefaultRealizationsValue || !strings.Contains(m.Lines[m.RealizationIndex], RealizationsKeyword) || !strings.Contains(m.Lines[m.SeedStringIndex], SeedKeyword) {
+		t.Errorf("expected the default realizations to be inserted before the seed")
+	}
+}
+
+const testMcaParameters = testMca + "\r\nParameter: Subbasin-1 Constant Rate\r\n     Element: Subbasin-1\r\n     Parameter Type: Constant Rate\r\n     Distribution: Normal\r\n     Mean: 0.1\r\n     Standard Deviation: 0.02\r\nEnd:\r\n\r\nParameter: Subbasin-2 Initial Loss\r\n     Element: Subbasin-2\r\n     Parameter Type: Initial Loss\r\n     Distribution: Uniform\r\n     Minimum: 0.1\r\n     Maximum: 0.5\r\nEnd:\r\n"
+
+func TestMcaUpdateParameter(t *testing.T) {
+	m, err := ReadMca([]byte(testMcaParameters))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Parameters) != 2 {
+		t.Fatalf("expected 2 parameter specifications got %v", len(m.Parameters))
+	}
+	p, err := m.Parameter("Subbasin-2", "Initial Loss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Distribution != "Uniform" {
+		t.Errorf("expected a uniform distribution got %v", p.Distribution)
+	}
+	err = m.UpdateParameter("Subbasin-1", "Constant Rate", map[string]string{"Distribution": "Lognormal", "Mean": "0.2", "Skew": "0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ = m.Parameter("Subbasin-1", "Constant Rate")
+	if p.Distribution != "Lognormal" {
+		t.Errorf("expected the distribution to be updated got %v", p.Distribution)
+	}
+	for property, expected := range map[string]string{"Mean": "0.2", "Skew": "0.5", "Standard Deviation": "0.02"} {
+		v, ok := m.ParameterValue(p, property)
+		if !ok || v != expected {
+			t.Errorf("expected %v to be %v got %v", property, expected, v)
+		}
+	}
+	p, _ = m.Parameter("Subbasin-2", "Initial Loss")
+	if v, _ := m.ParameterValue(p, "Maximum"); v != "0.5" {
+		t.Errorf("expected the second parameter to be unchanged after inserting lines, got maximum %v", v)
+	}
+	err = m.UpdateParameter("Subbasin-3", "Initial Loss", map[string]string{"Mean": "1"})
+	if err == nil {
+		t.Error("expected an error for a missing parameter specification")
+	}
+}
+
+const testControl = "Control: 2014 Event\r\n     Last Modified Date: 13 April 2022\r\n     Time Zone ID: America/Chicago\r\n     Time Zone GMT Offset: -21600000\r\n     Start Date: 27 June 2014\r\n     Start Time: 24:00\r\n     End Date: 6 July 2014\r\n     End Time: 24:00\r\n     Time Interval: 60\r\nEnd:\r\n"
+
+func TestControlWindow(t *testing.T) {
+	c, err := ReadControl([]byte(testControl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.EndDate != "7 July 2014" || c.EndTime != "00:00" || c.TimeInterval != 60 || c.TimeZoneID != "America/Chicago" {
+		t.Errorf("unexpected control window %v %v %v %v", c.EndDate, c.EndTime, c.TimeInterval, c.TimeZoneID)
+	}
+	start, err := c.ShiftWindow(24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end, _ := c.EndDateAndTime()
+	if end.Sub(start) != 9*24*time.Hour {
+		t.Errorf("expected the window duration to be preserved got %v", end.Sub(start))
+	}
+	storm := time.Date(2014, 1, 15, 6, 30, 0, 0, time.UTC)
+	err = c.WindowAroundStorm(storm, 48*time.Hour, 72*time.Hour, 12*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(c.ToBytes())
+	for _, expected := range []string{"     Start Date: 13 January 2014\r\n", "     Start Time: 06:00\r\n", "     End Date: 18 January 2014\r\n", "     End Time: 19:00\r\n", "     Time Zone GMT Offset: -21600000\r\n"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected %q in %v", expected, s)
+		}
+	}
+	err = c.SetWindow(storm, storm.Add(-time.Hour))
+	if err == nil {
+		t.Error("expected an error for an end before the start")
+	}
+}
+func TestNewControl(t *testing.T) {
+	start := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	c, err := NewControl("event", start, start.Add(96*time.Hour), 15, "America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = ReadControl(c.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.StartDate != "1 July 2020" || c.EndDate != "5 July 2020" || c.TimeInterval != 15 || c.TimeZoneGMTOffset != -21600000 {
+		t.Errorf("unexpected control %v", c)
+	}
+}
+
+const testBasin = "Basin: trinity\r\n     Description: test\r\nEnd:\r\n\r\nSubbasin: Subbasin-1\r\n     Area: 12.5\r\n     Loss: Deficit Constant\r\n     Initial Deficit: 1.0\r\nEnd:\r\n\r\nSubbasin: Subbasin-2\r\n     Area: 3.1\r\n     Initial Deficit: 0.5\r\nEnd:\r\n"
+
+func TestUpdateBasinElementParameter(t *testing.T) {
+	b, err := ReadBasin([]byte(testBasin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := b.Copy()
+	err = updated.UpdateElementParameter("Subbasin-2", "Initial Deficit", "2.25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := updated.ElementParameter("Subbasin-2", "Initial Deficit"); v != "2.25" {
+		t.Errorf("expected the initial deficit to be updated got %v", v)
+	}
+	if v, _ := updated.ElementParameter("Subbasin-1", "Initial Deficit"); v != "1.0" {
+		t.Errorf("expected the other subbasin to be unchanged got %v", v)
+	}
+	if v, _ := b.ElementParameter("Subbasin-2", "Initial Deficit"); v != "0.5" {
+		t.Errorf("expected the template to be unchanged got %v", v)
+	}
+	if string(b.ToBytes()) != testBasin {
+		t.Errorf("expected the basin to round trip")
+	}
+	if updated.UpdateElementParameter("Subbasin-3", "Initial Deficit", "1") == nil || updated.UpdateElementParameter("Subbasin-2", "Loss", "1") == nil {
+		t.Error("expected errors for a missing element and a missing parameter")
+	}
+}
+
+const testTemperatureGrid = "Grid: 1979-02-05 temperature\r\n     Grid Type: Temperature\r\n     Reference Height Units: Meters\r\n     Reference Height: 10.0\r\n       DSS File Name: data/1979-02-05.dss\r\n       DSS Pathname: /SHG4K/TEST/TEMPERATURE/04FEB1979:2400/05FEB1979:0100/AORC/\r\nEnd:\r\n"
+
+const testTemperatureMet = "Meteorology: test\r\n     Precipitation Method: Gridded Precipitation\r\n     Air Temperature Method: Gridded Temperature\r\nEnd:\r\n\r\nPrecip Method Parameters: Gridded Precipitation\r\n     Precip Grid Name: AORC 1979-02-05\r\n     Time Shift Method: NORMALIZE\r\nEnd:\r\n\r\nAir Temperature Method Parameters: Gridded Temperature\r\n     Temperature Grid Name: 1979-02-05 temperature\r\n     Time Shift Method: NORMALIZE\r\nEnd:\r\n"
+
+func TestPairTemperatureGrid(t *testing.T) {
+	g, err := ReadGrid([]byte(testGrid + "\r\n" + testTemperatureGrid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Temps) != 1 || g.Temps[0].StartTime != "04FEB1979:2359" {
+		t.Fatalf("expected one temperature grid starting 04FEB1979:2359 got %v", g.Temps)
+	}
+	for _, l := range g.GridFileInfo.Lines {
+		if strings.Contains(l, "temperature") {
+			t.Errorf("expected the temperature grid to be removed from the grid file info got %v", l)
+		}
+	}
+	temp := g.PairedTemperature(g.Events[0])
+	if temp.Name != "1979-02-05 temperature" {
+		t.Errorf("expected AORC 1979-02-05 to pair with 1979-02-05 temperature got %v", temp.Name)
+	}
+	if g.PairedTemperature(g.Events[1]).Name != "" {
+		t.Errorf("expected AORC 1980-03-06 to not have a temperature grid")
+	}
+	synthesized := g.Events[1].SynthesizeTemperature()
+	s := string(g.ToBytes(g.Events[1], synthesized))
+	if synthesized.Name != "AORC 1980-03-06 temperature" || !strings.Contains(s, "Grid: AORC 1980-03-06 temperature\r\n     Grid Type: Temperature\r\n") || !strings.Contains(s, "/SHG4K/TEST/TEMPERATURE/06MAR1980:0100/06MAR1980:0200/AORC/") {
+		t.Errorf("expected a temperature grid to be synthesized from the precipitation grid got\n%v", s)
+	}
+	if strings.Count(s, "Grid: AORC 1980-03-06\r\n") != 1 {
+		t.Errorf("expected the synthesized temperature grid to have a distinct name")
+	}
+	if strings.Count(s, GridStormCenterXKeyword) != 1 {
+		t.Errorf("expected storm centers to only be written for the precipitation grid")
+	}
+}
+func TestUpdateTemperatureTimeShift(t *testing.T) {
+	m, err := ReadMet([]byte(testTemperatureMet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.HasTemperatureGrid() {
+		t.Fatal("expected the met model to have a temperature grid")
+	}
+	control := time.Date(2017, 9, 17, 1, 0, 0, 0, time.UTC)
+	grid := time.Date(2017, 9, 16, 23, 0, 0, 0, time.UTC)
+	m.UpdateStormName("AORC 1980-03-06")
+	m.UpdateTempGridName("AORC 1980-03-06")
+	m.UpdatePrecipTimeShift(false, control, grid, 0)
+	m.UpdateTempTimeShift(false, control, grid, 0)
+	b, _ := m.WriteBytes()
+	s := string(b)
+	if !strings.Contains(s, TempGridNameKeyword+"AORC 1980-03-06") {
+		t.Errorf("expected the temperature grid name to be updated got\n%v", s)
+	}
+	if strings.Count(s, TimeShiftKeyword+"-120") != 2 || strings.Count(s, TimeShiftMethodKeyword+"SPECIFIED") != 2 {
+		t.Errorf("expected precipitation and temperature to be shifted together got\n%v", s)
+	}
+	noTemp, _ := ReadMet([]byte(strings.Split(testTemperatureMet, "\r\n\r\nAir")[0] + "\r\n"))
+	if noTemp.HasTemperatureGrid() || noTemp.UpdateTempGridName("x") == nil {
+		t.Error("expected a met model without temperature to not update a temperature grid name")
+	}
+}
+
+const testSweGrids = "Grid: SWE 1979-02-05\r\n     Grid Type: Snow Water Equivalent\r\n       DSS File Name: data/1979-02-05.dss\r\n       DSS Pathname: /SHG4K/TEST/SWE/04FEB1979:2400//SNODAS/\r\nEnd:\r\n\r\nGrid: SWE 1980-03-06\r\n     Grid Type: Snow Water Equivalent\r\n       DSS File Name: data/1980-03-06.dss\r\n       DSS Pathname: /SHG4K/TEST/SWE/06MAR1980:0000//SNODAS/\r\nEnd:\r\n\r\nGrid: Elevation\r\n     Grid Type: Elevation\r\nEnd:\r\n"
+
+func TestCompanionGrids(t *testing.T) {
+	g, err := ReadGrid([]byte(testGrid + "\r\n" + testSweGrids))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.ExtractCompanions([]string{"snow"})
+	if err == nil {
+		t.Error("expected an error for an unknown companion grid type")
+	}
+	c, err := g.ExtractCompanions([]string{"swe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Companions) != 2 || c.Companions[0].Type != "swe" || c.Companions[0].StartTime != "04FEB1979:2359" {
+		t.Fatalf("expected two swe grids got %v", c.Companions)
+	}
+	paired, err := c.PairedCompanions(c.Events[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paired) != 1 || paired[0].Name != "SWE 1980-03-06" {
+		t.Fatalf("expected AORC 1980-03-06 to pair with SWE 1980-03-06 got %v", paired)
+	}
+	paired[0].UpdateDSSFile("Storm")
+	s := string(c.ToBytes(c.Events[1], TempGridEvent{}, paired...))
+	if strings.Contains(s, "SWE 1979-02-05") || strings.Count(s, "Grid: SWE 1980-03-06") != 1 || !strings.Contains(s, "Grid: Elevation") {
+		t.Errorf("expected only the paired swe grid and the other grids to be written got\n%v", s)
+	}
+	if !strings.Contains(c.Companions[1].Lines[2], "data/1980-03-06.dss") {
+		t.Errorf("expected updating the paired grid to not modify the catalog")
+	}
+	_, err = c.PairedCompanions(PrecipGridEvent{Name: "AORC 1981-01-01"})
+	if err == nil {
+		t.Error("expected an error for a storm without a swe grid")
+	}
+	m, err := ReadMet([]byte(testTemperatureMet + "\r\nSnowmelt Method Parameters: Gridded Temperature Index\r\n     SWE Grid Name: SWE 1979-02-05\r\n     Cold Content Grid Name: CC 1979-02-05\r\nEnd:\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	control := time.Date(1980, 3, 6, 2, 0, 0, 0, time.UTC)
+	err = m.UpdateCompanionGrid("swe", paired[0].Name, false, control, time.Date(1980, 3, 6, 0, 0, 0, 0, time.UTC), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := m.WriteBytes()
+	s = string(b)
+	if !strings.Contains(s, "     SWE Grid Name: SWE 1980-03-06\r\n     Cold Content Grid Name: CC 1979-02-05\r\n     Time Shift Method: SPECIFIED\r\n     Time Shift: -120\r\nEnd:") {
+		t.Errorf("expected the swe grid name and time shift to be updated got\n%v", s)
+	}
+	if m.UpdateCompanionGrid("wind", "x", true, control, control, 0) == nil {
+		t.Error("expected an error for a met model without a windspeed method block")
+	}
+}
+func TestUpdateStormCenter(t *testing.T) {
+	uncentered := strings.Replace(testGrid, "     Storm Center X: 300\r\n     Storm Center Y: 400\r\n", "", 1)
+	g, err := ReadGrid([]byte(uncentered))
+	if err != nil || len(g.Events) != 1 {
+		t.Fatalf("expected grids without storm centers to be skipped got %v events", len(g.Events))
+	}
+	g, err = ReadGridWithoutCenters([]byte(uncentered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Events) != 2 || !g.Events[0].HasStormCenter() || g.Events[1].HasStormCenter() {
+		t.Fatalf("expected both grids with one storm center got %v events", len(g.Events))
+	}
+	g.Events[1].UpdateStormCenter(350.5, 450.25)
+	g.Events[0].UpdateStormCenter(1, 2)
+	updated, err := ReadGrid(g.CatalogBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Events) != 2 || updated.Events[0].CenterX != 1 || updated.Events[0].CenterY != 2 || updated.Events[1].CenterX != 350.5 || updated.Events[1].CenterY != 450.25 {
+		t.Errorf("expected the storm centers to be written to the grid file got %v", updated.Events)
+	}
+	if strings.Count(string(g.CatalogBytes()), GridStormCenterXKeyword) != 2 {
+		t.Errorf("expected each grid to have one storm center")
+	}
+}
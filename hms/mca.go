@@ -3,20 +3,41 @@ package hms
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 var SeedKeyword string = "     Seed Value: "
 var RealizationsKeyword string = "     Number Of Realizations: "
 var DefaultRealizationsValue int = 1
+var ParameterStartKeyword string = "Parameter: "
+var ParameterEndKeyword string = "End:"
+var ParameterElementKeyword string = "     Element: "
+var ParameterTypeKeyword string = "     Parameter Type: "
+var ParameterDistributionKeyword string = "     Distribution: "
+var ParameterPropertyIndent string = "     "
 
 type Mca struct {
 	SeedStringIndex  int
 	HasRealizations  bool
 	RealizationIndex int
+	RealizationCount int
+	Parameters       []McaParameter
 	Lines            []string
 }
 
+// McaParameter is a parameter sampling specification, it describes the distribution a basin element parameter is sampled from.
+// StartIndex and EndIndex are the line indexes of the parameter header and end lines.
+type McaParameter struct {
+	Name         string
+	Element      string
+	Parameter    string
+	Distribution string
+	StartIndex   int
+	EndIndex     int
+}
+
 func ReadMca(mcaResource []byte) (Mca, error) {
 	//read bytes
 	//loop through and find met and precip blocks
@@ -26,6 +47,7 @@ func ReadMca(mcaResource []byte) (Mca, error) {
 	hasRealizations := false
 	seedStringIndex := 0
 	realizationIndex := 0
+	realizationCount := DefaultRealizationsValue
 	for idx, l := range lines {
 		if strings.Contains(l, SeedKeyword) {
 			seedFound = true
@@ -34,23 +56,26 @@ func ReadMca(mcaResource []byte) (Mca, error) {
 		if strings.Contains(l, RealizationsKeyword) {
 			hasRealizations = true
 			realizationIndex = idx
+			count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(l, RealizationsKeyword)))
+			if err != nil || count < 1 {
+				return Mca{}, fmt.Errorf("could not parse the number of realizations from %v", l)
+			}
+			realizationCount = count
 		}
 	}
-	if !hasRealizations { //if the file doesnt specifiy realizations force it to be 1.
+	if !hasRealizations { //if the file doesnt specifiy realizations use the default, a realizations table can update it.
 		lines = append(lines[:seedStringIndex+1], lines[seedStringIndex:]...)
-		lines[seedStringIndex] = fmt.Sprintf("%v%v", RealizationsKeyword, 1)
+		lines[seedStringIndex] = fmt.Sprintf("%v%v", RealizationsKeyword, DefaultRealizationsValue)
 		realizationIndex = seedStringIndex
 		seedStringIndex = seedStringIndex + 1
 		hasRealizations = true
-	} else {
-		//force to 1
-		//@TODO allow user to specify they want more than one?
-		lines[realizationIndex] = fmt.Sprintf("%v%v", RealizationsKeyword, 1)
 	}
 	mcaModel := Mca{
 		SeedStringIndex:  seedStringIndex,
 		HasRealizations:  hasRealizations,
 		RealizationIndex: realizationIndex,
+		RealizationCount: realizationCount,
+		Parameters:       readParameters(lines),
 		Lines:            lines,
 	}
 	if seedFound {
@@ -64,9 +89,117 @@ func (mf *Mca) UpdateSeed(seed int64) error {
 	return nil
 }
 func (mf *Mca) UpdateRealizations(count int) error {
+	if count < 1 {
+		return fmt.Errorf("cannot set the number of realizations to %v, at least 1 realization is required", count)
+	}
 	mf.Lines[mf.RealizationIndex] = fmt.Sprintf("%v%v", RealizationsKeyword, count)
+	mf.RealizationCount = count
+	return nil
+}
+
+// UpdateEvent sets the seed and the number of realizations for an event from the realizations table.
+func (mf *Mca) UpdateEvent(realizations Realizations, eventNumber int64, stormName string, seed int64) error {
+	count, err := realizations.Count(eventNumber, stormName)
+	if err != nil {
+		return err
+	}
+	err = mf.UpdateRealizations(count)
+	if err != nil {
+		return err
+	}
+	return mf.UpdateSeed(seed)
+}
+func readParameters(lines []string) []McaParameter {
+	parameters := make([]McaParameter, 0)
+	inParameter := false
+	var p McaParameter
+	for idx, l := range lines {
+		if strings.HasPrefix(l, ParameterStartKeyword) {
+			inParameter = true
+			p = McaParameter{Name: strings.TrimPrefix(l, ParameterStartKeyword), StartIndex: idx}
+			continue
+		}
+		if !inParameter {
+			continue
+		}
+		if strings.HasPrefix(l, ParameterElementKeyword) {
+			p.Element = strings.TrimPrefix(l, ParameterElementKeyword)
+		}
+		if strings.HasPrefix(l, ParameterTypeKeyword) {
+			p.Parameter = strings.TrimPrefix(l, ParameterTypeKeyword)
+		}
+		if strings.HasPrefix(l, ParameterDistributionKeyword) {
+			p.Distribution = strings.TrimPrefix(l, ParameterDistributionKeyword)
+		}
+		if strings.HasPrefix(l, ParameterEndKeyword) {
+			inParameter = false
+			p.EndIndex = idx
+			parameters = append(parameters, p)
+		}
+	}
+	return parameters
+}
+
+// Parameter finds the sampling specification for a parameter of a basin element.
+func (mf Mca) Parameter(element string, parameter string) (McaParameter, error) {
+	for _, p := range mf.Parameters {
+		if p.Element == element && p.Parameter == parameter {
+			return p, nil
+		}
+	}
+	return McaParameter{}, fmt.Errorf("no sampling specification was found for parameter %v of element %v", parameter, element)
+}
+
+// ParameterValue returns the value of a property (for example Mean or Standard Deviation) of a parameter sampling specification.
+func (mf Mca) ParameterValue(p McaParameter, property string) (string, bool) {
+	prefix := fmt.Sprintf("%v%v: ", ParameterPropertyIndent, property)
+	for _, l := range mf.Lines[p.StartIndex:p.EndIndex] {
+		if strings.HasPrefix(l, prefix) {
+			return strings.TrimPrefix(l, prefix), true
+		}
+	}
+	return "", false
+}
+
+// UpdateParameter sets properties of a parameter sampling specification, properties that do not exist are added to the end of the specification.
+func (mf *Mca) UpdateParameter(element string, parameter string, properties map[string]string) error {
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) //keep added lines in a stable order.
+	for _, property := range keys {
+		p, err := mf.Parameter(element, parameter)
+		if err != nil {
+			return err
+		}
+		prefix := fmt.Sprintf("%v%v: ", ParameterPropertyIndent, property)
+		line := fmt.Sprintf("%v%v", prefix, properties[property])
+		found := false
+		for idx := p.StartIndex; idx < p.EndIndex; idx++ {
+			if strings.HasPrefix(mf.Lines[idx], prefix) {
+				mf.Lines[idx] = line
+				found = true
+				break
+			}
+		}
+		if !found {
+			mf.insertLine(p.EndIndex, line)
+		}
+		mf.Parameters = readParameters(mf.Lines)
+	}
 	return nil
 }
+func (mf *Mca) insertLine(idx int, line string) {
+	mf.Lines = append(mf.Lines[:idx+1], mf.Lines[idx:]...)
+	mf.Lines[idx] = line
+	if mf.SeedStringIndex >= idx {
+		mf.SeedStringIndex++
+	}
+	if mf.RealizationIndex >= idx {
+		mf.RealizationIndex++
+	}
+}
 func (mf Mca) ToBytes() []byte {
 	b := make([]byte, 0)
 	for _, l := range mf.Lines {
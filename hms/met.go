@@ -131,12 +131,28 @@ func (m *Met) UpdateStormName(stormName string) error {
 			m.PrecipMethodParameters.lines[idx] = fmt.Sprintf("%v%v", PrecipGridNameKeyword, stormName)
 		}
 	}
-	/*for idx, l := range m.tempmethod.lines {
+	return nil
+}
+
+// HasTemperatureGrid is true if the met model has gridded air temperature method parameters.
+func (m Met) HasTemperatureGrid() bool {
+	for _, l := range m.tempmethod.lines {
 		if strings.Contains(l, TempGridNameKeyword) {
-			m.tempmethod.lines[idx] = fmt.Sprintf("%v%v", TempGridNameKeyword, stormName)
+			return true
 		}
-	}*/
-	return nil
+	}
+	return false
+}
+
+// UpdateTempGridName sets the temperature grid name, temperature grids are named independently of the precipitation grid they are paired with.
+func (m *Met) UpdateTempGridName(gridName string) error {
+	for idx, l := range m.tempmethod.lines {
+		if strings.Contains(l, TempGridNameKeyword) {
+			m.tempmethod.lines[idx] = fmt.Sprintf("%v%v", TempGridNameKeyword, gridName)
+			return nil
+		}
+	}
+	return errors.New("the met model does not have a temperature grid name")
 }
 func (m *Met) UpdatePrecipTimeShift(normalize bool, controlStartTime time.Time, gridStartTime time.Time, userSpecifiedAdditionalTime int) error {
 	foundTimeShift := false
@@ -185,7 +201,11 @@ func (m *Met) UpdateTempTimeShift(normalize bool, controlStartTime time.Time, gr
 			m.tempmethod.lines[idx] = fmt.Sprintf("%v%v", TimeShiftKeyword, timeShiftInt)
 		} else {
 			if strings.Contains(l, TimeShiftMethodKeyword) {
-				m.tempmethod.lines[idx] = fmt.Sprintf("%v%v", TimeShiftMethodKeyword, "NORMALIZE")
+				if normalize {
+					m.tempmethod.lines[idx] = fmt.Sprintf("%v%v", TimeShiftMethodKeyword, "NORMALIZE")
+				} else {
+					m.tempmethod.lines[idx] = fmt.Sprintf("%v%v", TimeShiftMethodKeyword, "SPECIFIED")
+				}
 				foundTimeShiftMethod = true
 			}
 		}
@@ -199,12 +219,76 @@ func (m *Met) UpdateTempTimeShift(normalize bool, controlStartTime time.Time, gr
 	if !foundTimeShiftMethod {
 		if normalize {
 			m.tempmethod.lines = append(m.tempmethod.lines, fmt.Sprintf("%v%v", TimeShiftMethodKeyword, "NORMALIZE"))
+		} else {
+			m.tempmethod.lines = append(m.tempmethod.lines, fmt.Sprintf("%v%v", TimeShiftMethodKeyword, "SPECIFIED"))
 		}
 
 	}
 	return nil
 }
 
+// UpdateCompanionGrid sets the grid name and time shift of a companion grid (see CompanionGridTypes) in its method parameters block
+// so the companion grid is time aligned with precipitation. Time shift lines that do not exist are added at the end of the block.
+func (m *Met) UpdateCompanionGrid(companionType string, gridName string, normalize bool, controlStartTime time.Time, gridStartTime time.Time, userSpecifiedAdditionalTime int) error {
+	definition, ok := CompanionGridTypes[companionType]
+	if !ok {
+		return fmt.Errorf("%v is not a companion grid type", companionType)
+	}
+	lines := strings.Split(m.metString, "\r\n")
+	start := -1
+	end := -1
+	for idx, l := range lines {
+		if start == -1 {
+			if strings.HasPrefix(l, definition.MetStartKeyword) {
+				start = idx
+			}
+		} else if strings.HasPrefix(l, PrecipEndKeyword) {
+			end = idx
+			break
+		}
+	}
+	if start == -1 || end == -1 {
+		return fmt.Errorf("the met model does not have %v for the %v grid", definition.MetStartKeyword, companionType)
+	}
+	timeShiftFloat := math.Round(-controlStartTime.Sub(gridStartTime).Minutes())
+	timeShiftInt := int(timeShiftFloat) + userSpecifiedAdditionalTime //negative is forward in time.
+	timeShiftMethod := "SPECIFIED"
+	if normalize {
+		timeShiftMethod = "NORMALIZE"
+	}
+	foundName := false
+	foundTimeShift := false
+	foundTimeShiftMethod := false
+	for idx := start; idx < end; idx++ {
+		if strings.HasPrefix(lines[idx], definition.MetGridNameKeyword) {
+			foundName = true
+			lines[idx] = fmt.Sprintf("%v%v", definition.MetGridNameKeyword, gridName)
+		} else if strings.HasPrefix(lines[idx], TimeShiftKeyword) {
+			foundTimeShift = true
+			lines[idx] = fmt.Sprintf("%v%v", TimeShiftKeyword, timeShiftInt)
+		} else if strings.HasPrefix(lines[idx], TimeShiftMethodKeyword) {
+			foundTimeShiftMethod = true
+			lines[idx] = fmt.Sprintf("%v%v", TimeShiftMethodKeyword, timeShiftMethod)
+		}
+	}
+	if !foundName {
+		return fmt.Errorf("the met model does not have a %v grid name", companionType)
+	}
+	added := make([]string, 0)
+	if !foundTimeShiftMethod {
+		added = append(added, fmt.Sprintf("%v%v", TimeShiftMethodKeyword, timeShiftMethod))
+	}
+	if !foundTimeShift && !normalize {
+		added = append(added, fmt.Sprintf("%v%v", TimeShiftKeyword, timeShiftInt))
+	}
+	updated := make([]string, 0, len(lines)+len(added))
+	updated = append(updated, lines[:end]...)
+	updated = append(updated, added...)
+	updated = append(updated, lines[end:]...)
+	m.metString = strings.Join(updated, "\r\n")
+	return nil
+}
+
 /*
 	func (m *Met) UpdateTimeShift(timeShift string) error {
 		foundTimeShift := false
@@ -1,29 +1,63 @@
 package hms
 
 import (
+	"fmt"
+	"path"
 	"strconv"
 	"strings"
 )
 
+// Realizations is a table of the number of parameter uncertainty realizations to run in an hms monte carlo analysis,
+// keyed by event number or storm name.
 type Realizations struct {
 	Query map[string]int
 }
 
+// ReadCsv reads a csv with a header and event,realizations rows, the event column is either an event number or a storm name.
 func ReadCsv(csvBytes []byte) (Realizations, error) {
-	return Realizations{}, nil
-
-	csvstring := string(csvBytes)
-	lines := strings.Split(csvstring, "\r\n") //maybe rn?
+	csvstring := strings.ReplaceAll(string(csvBytes), "\r\n", "\n")
+	lines := strings.Split(csvstring, "\n")
 	data := make(map[string]int)
 	for idx, l := range lines {
-		if idx != 0 {
-			values := strings.Split(l, ", ")
-			reals, err := strconv.Atoi(values[1])
-			if err != nil {
-				return Realizations{}, err
-			}
-			data[values[0]] = reals
+		if idx == 0 || strings.TrimSpace(l) == "" {
+			continue //skip the header and empty lines
+		}
+		values := strings.Split(l, ",")
+		if len(values) < 2 {
+			return Realizations{}, fmt.Errorf("realizations line %v does not have an event and a realization count", idx+1)
+		}
+		key := strings.TrimSpace(values[0])
+		if key == "" {
+			return Realizations{}, fmt.Errorf("realizations line %v does not have an event", idx+1)
+		}
+		reals, err := strconv.Atoi(strings.TrimSpace(values[1]))
+		if err != nil {
+			return Realizations{}, fmt.Errorf("could not parse the realization count for %v: %v", key, err)
+		}
+		if reals < 1 {
+			return Realizations{}, fmt.Errorf("%v has %v realizations, at least 1 realization is required", key, reals)
+		}
+		if _, ok := data[key]; ok {
+			return Realizations{}, fmt.Errorf("%v is listed more than once in the realizations table", key)
 		}
+		data[key] = reals
+	}
+	if len(data) == 0 {
+		return Realizations{}, fmt.Errorf("no realizations were found")
 	}
 	return Realizations{Query: data}, nil
 }
+
+// Count finds the realization count for an event, the event number is checked first, then the storm name and the storm name without its extension.
+func (r Realizations) Count(eventNumber int64, stormName string) (int, error) {
+	keys := []string{fmt.Sprint(eventNumber), stormName, strings.TrimSuffix(stormName, path.Ext(stormName))}
+	for _, k := range keys {
+		if k == "" {
+			continue
+		}
+		if count, ok := r.Query[k]; ok {
+			return count, nil
+		}
+	}
+	return 0, fmt.Errorf("event %v (%v) does not have a realization count", eventNumber, stormName)
+}
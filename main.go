@@ -34,7 +34,7 @@ func main() {
 	}
 	// get the payload.
 	payload := pm.Payload
-	controlStartTime := time.Now() //introduces a dependency of select random basin for single stochastic transposition. consider consolidating into one action to remove the dependency.
+	controlStartTime := time.Now() //introduces a dependency of select random basin for single stochastic transposition. compose_event consolidates both actions to remove the dependency.
 	for _, a := range payload.Actions {
 		switch a.Type {
 		case "select_random_basin":
@@ -67,91 +67,134 @@ func main() {
 				pm.Logger.Error(err.Error())
 				return
 			}
-			gridFileBytes, err := getInputBytes("HMS Model", ".grid", payload, pm)
+			output, dssBytes, err := stochasticTransposition(a, payload, pm, seedSet, controlStartTime)
 			if err != nil {
 				pm.Logger.Error(err.Error())
 				return
 			}
-			metFileBytes, err := getInputBytes("HMS Model", ".met", payload, pm)
+			err = putStochasticTransposition(output, dssBytes, payload, pm)
 			if err != nil {
 				pm.Logger.Error(err.Error())
 				return
 			}
-
-			transpositionDomainBytes, err := getInputBytes("TranspositionRegion", "", payload, pm)
+		case "compose_event":
+			//transposes the storm, then selects the basin and control for the storm, the time shift is relative to the selected control start so the results do not depend on action order.
+			seedSet, err := getSeeds(payload, pm)
 			if err != nil {
 				pm.Logger.Error(err.Error())
 				return
 			}
-			watershedDomainBytes, err := getInputBytes("WatershedBoundary", "", payload, pm)
+			basinDS, err := pm.GetInputDataSource("Input_Basin_Directory")
 			if err != nil {
 				pm.Logger.Error(err.Error())
 				return
 			}
-			gridFile, err := hms.ReadGrid(gridFileBytes)
+			outBasinDS, err := pm.GetOutputDataSource("Output_Basin_Directory")
 			if err != nil {
 				pm.Logger.Error(err.Error())
 				return
 			}
-			metFile, err := hms.ReadMet(metFileBytes)
+			//the storm is transposed first so the basin can be selected by the storm type, the time shift is computed from the selected control start.
+			run, err := initStochasticTransposition(a, payload, pm, seedSet)
 			if err != nil {
 				pm.Logger.Error(err.Error())
 				return
 			}
-
-			sst := actions.InitSingleStochasticTransposition(pm, gridFile, metFile, seedSet, transpositionDomainBytes, watershedDomainBytes)
-			bootstrapCatalogString := a.Attributes.GetStringOrDefault("bootstrap_catalog", "false")
-			bootstrapCatalog, err := strconv.ParseBool(bootstrapCatalogString)
+			storm, err := run.sst.Transpose(run.bootstrapCatalog, run.bootstrapOptions)
 			if err != nil {
-				pm.Logger.Error("could not parse bootstrap_catalog parameter")
+				pm.Logger.Error(err.Error())
 				return
 			}
-			bootstrapCatalogLength := a.Attributes.GetIntOrDefault("bootstrap_catalog_length", len(gridFile.Events))
-			if len(gridFile.Events) < bootstrapCatalogLength {
-				pm.Logger.Error("cannot allow bootstrap_catalog_length to be greater than the catalog length")
+			srb := actions.InitSelectBasinAction(a, seedSet, basinDS, outBasinDS)
+			selected, err := srb.Sample(storm.StormType)
+			if err != nil {
+				pm.Logger.Error(err.Error())
 				return
 			}
-			normalizeTimeShiftString := a.Attributes.GetStringOrDefault("normalize", "true")
-			normalizeTimeShift, err := strconv.ParseBool(normalizeTimeShiftString)
-			userSpecifiedOffset := a.Attributes.GetIntOrDefault("start_time_offset", 0)
+			output, dssBytes, err := timeShiftStochasticTransposition(pm, run, storm, selected.ControlStart)
 			if err != nil {
-				pm.Logger.Error("could not parse normalize parameter")
+				pm.Logger.Error(err.Error())
 				return
 			}
-			output, err := sst.Compute(bootstrapCatalog, bootstrapCatalogLength, normalizeTimeShift, controlStartTime, userSpecifiedOffset)
+			err = srb.Put(selected)
 			if err != nil {
-				pm.Logger.Error("could not compute payload")
+				pm.Logger.Error(err.Error())
 				return
 			}
-			dssGridCacheDataSource, err := pm.GetInputDataSource("DSS Grid Cache")
+			err = putStochasticTransposition(output, dssBytes, payload, pm)
 			if err != nil {
-				pm.Logger.Error("could not find DSS Grid Cache datasource")
+				pm.Logger.Error(err.Error())
 				return
 			}
-			root := dssGridCacheDataSource.Paths["default"]
-			stormName := strings.Replace(output.StormName, "\\", "/", -1)
-			stormDataSource := cc.DataSource{
-				Name:      "DssFile",
-				ID:        &uuid.NameSpaceDNS,
-				Paths:     map[string]string{"default": fmt.Sprintf("%v%v", root, stormName)},
-				StoreName: dssGridCacheDataSource.StoreName,
+		case "mca_mutation":
+			seedSet, err := getSeeds(payload, pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
 			}
-			dssBytes, err := utils.GetFile(*pm, stormDataSource, "default")
+			mcaFileBytes, err := getInputBytes("HMS Model", ".mca", payload, pm)
 			if err != nil {
-				pm.Logger.Error("could not find storm")
+				pm.Logger.Error(err.Error())
 				return
 			}
-			err = putOutputBytes(dssBytes, "Storm DSS File", payload, pm)
+			mcaFile, err := hms.ReadMca(mcaFileBytes)
 			if err != nil {
-				pm.Logger.Error("could not put storm")
+				pm.Logger.Error(err.Error())
 				return
 			}
-			err = putOutputBytes(output.GridBytes, "Grid File", payload, pm)
+			var realizations *hms.Realizations
+			if a.Attributes.GetBooleanOrDefault("use_realizations_table", false) {
+				realizationsBytes, err := getInputBytes("Realizations", "", payload, pm)
+				if err != nil {
+					pm.Logger.Error(err.Error())
+					return
+				}
+				table, err := hms.ReadCsv(realizationsBytes)
+				if err != nil {
+					pm.Logger.Error(err.Error())
+					return
+				}
+				realizations = &table
+			}
+			mma := actions.InitMcaMutationAction(a, seedSet, mcaFile, realizations)
+			mcaBytes, err := mma.Compute()
 			if err != nil {
-				pm.Logger.Error("could not put grid file")
+				pm.Logger.Error(err.Error())
+				return
+			}
+			err = putOutputBytes(mcaBytes, "Mca File", payload, pm)
+			if err != nil {
+				pm.Logger.Error("could not put mca file")
+				return
+			}
+		case "derive_storm_centers":
+			gridFileBytes, err := getInputBytes("HMS Model", ".grid", payload, pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			gridFile, err := hms.ReadGridWithoutCenters(gridFileBytes)
+			if err != nil {
+				pm.Logger.Error(err.Error())
 				return
 			}
-			err = putOutputBytes(output.MetBytes, "Met File", payload, pm)
+			rasters, err := pm.GetInputDataSource("Storm Rasters")
+			if err != nil {
+				pm.Logger.Error("could not find Storm Rasters datasource")
+				return
+			}
+			dsca := actions.InitDeriveStormCentersAction(a, gridFile)
+			gridBytes, centers, err := dsca.Compute(rasters.Paths["default"])
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			for _, c := range centers {
+				if c.Derived {
+					pm.Logger.Info(fmt.Sprintf("derived storm center %v,%v for %v", c.Center.X, c.Center.Y, c.Name))
+				}
+			}
+			err = putOutputBytes(gridBytes, "Grid File", payload, pm)
 			if err != nil {
 				pm.Logger.Error("could not put grid file")
 				return
@@ -183,6 +226,7 @@ func main() {
 				pm.Logger.Error("could not initalize stratified locations for this payload")
 				return
 			}
+			sla.Logger = pm.Logger
 			output, err := sla.Compute()
 			//put the output
 
@@ -233,21 +277,60 @@ func main() {
 				pm.Logger.Error("could not initalize valid stratified locations for this payload")
 				return
 			}
-			//inputSource, err := pm.GetInputDataSource("Cumulative Grids")
+			sla.Logger = pm.Logger
+			terrain, err := getTerrainConstraint(a, payload, pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			err = sla.SetTerrainConstraint(terrain)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
 			outputDataSource, err := a.GetOutputDataSource("ValidLocations")
 			if err != nil {
 				pm.Logger.Error("could not put valid stratified locations for this payload")
 			}
 			root := outputDataSource.Paths["default"]
-			output, err := sla.DetermineValidLocationsQuickly(pm.IOManager) //sla.DetermineValidLocations(inputSource) //update to be based on output location?
-			if err != nil {
-				pm.Logger.Error("could not compute valid stratified locations for this payload")
-				return
+			//the geometry engine checks the shifted watershed is inside the transposition region, the raster engine checks the storm depth over the shifted watershed.
+			rasterEngine := a.Attributes.GetStringOrDefault("placement_engine", "geometry") == "raster"
+			var output actions.ValidLocationsComputeResult
+			if rasterEngine {
+				inputSource, err := pm.GetInputDataSource("Cumulative Grids")
+				if err != nil {
+					pm.Logger.Error("could not find Cumulative Grids datasource")
+					return
+				}
+				output, err = sla.DetermineValidLocations(inputSource)
+				if err != nil {
+					pm.Logger.Error(err.Error())
+					return
+				}
+				for name, locations := range output.StormMap {
+					pm.Logger.Info(fmt.Sprintf("found %v valid placements for %v", len(locations.Coordinates), name))
+					outputDataSource.Paths["default"] = fmt.Sprintf("%v/%v", root, name)
+					err = utils.PutFile(locations.ToBytes(), pm.IOManager, outputDataSource, "default")
+					if err != nil {
+						pm.Logger.Error(err.Error())
+						return
+					}
+				}
+			} else {
+				output, err = sla.DetermineValidLocationsQuickly(pm.IOManager)
+				if err != nil {
+					pm.Logger.Error("could not compute valid stratified locations for this payload")
+					return
+				}
 			}
 
 			outputDataSource.Paths["default"] = fmt.Sprintf("%v/%v.csv", root, "AllStormsAllLocations")
 			outbytes := make([]byte, 0)
-			outbytes = append(outbytes, "StormName,X,Y,IsValid"...)
+			if rasterEngine {
+				outbytes = append(outbytes, "StormName,X,Y,IsValid,MeanDepth,MaxDepth,Volume\n"...)
+			} else {
+				outbytes = append(outbytes, "StormName,X,Y,IsValid"...)
+			}
 			//create random list of ints
 			indexes := make([]int, len(output.AllStormsAllLocations))
 			rand := rand.New(rand.NewSource(945631))
@@ -259,9 +342,56 @@ func main() {
 				indexes[j] = i
 			}
 			for i, _ := range output.AllStormsAllLocations {
-				outbytes = append(outbytes, fmt.Sprintf("%v,%v,%v,%v\n", output.AllStormsAllLocations[indexes[i]].StormName, output.AllStormsAllLocations[indexes[i]].Coordinate.X, output.AllStormsAllLocations[indexes[i]].Coordinate.Y, output.AllStormsAllLocations[indexes[i]].IsValid)...)
+				li := output.AllStormsAllLocations[indexes[i]]
+				if rasterEngine {
+					outbytes = append(outbytes, fmt.Sprintf("%v,%v,%v,%v,%v,%v,%v\n", li.StormName, li.Coordinate.X, li.Coordinate.Y, li.IsValid, li.Statistics.Mean, li.Statistics.Max, li.Statistics.Volume)...)
+				} else {
+					outbytes = append(outbytes, fmt.Sprintf("%v,%v,%v,%v\n", li.StormName, li.Coordinate.X, li.Coordinate.Y, li.IsValid)...)
+				}
 			}
 			utils.PutFile(outbytes, pm.IOManager, outputDataSource, "default")
+		case "basin_average_depths":
+			gridFileBytes, err := getInputBytes("HMS Model", ".grid", payload, pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			transpositionDomainBytes, err := getInputBytes("TranspositionRegion", "", payload, pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			watershedDomainBytes, err := getInputBytes("WatershedBoundary", "", payload, pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			gridFile, err := hms.ReadGrid(gridFileBytes)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			sla, err := actions.InitStratifiedCompute(a, gridFile, transpositionDomainBytes, watershedDomainBytes)
+			if err != nil {
+				pm.Logger.Error("could not initalize basin average depths for this payload")
+				return
+			}
+			sla.Logger = pm.Logger
+			inputSource, err := pm.GetInputDataSource("Cumulative Grids")
+			if err != nil {
+				pm.Logger.Error("could not find Cumulative Grids datasource")
+				return
+			}
+			depths, err := sla.BasinAverageDepths(inputSource)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+			err = actions.PutBasinAverageDepths(depths, pm.IOManager)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
 		case "storm_typed_normal_density_locations": //aka fishnets
 			gridFileBytes, err := getInputBytes("HMS Model", ".grid", payload, pm)
 			if err != nil {
@@ -289,6 +419,7 @@ func main() {
 				pm.Logger.Error("could not initalize locations for this payload")
 				return
 			}
+			sla.Logger = pm.Logger
 			err = sla.DetermineStormTypeNormalDensityKernelLocations(a.IOManager) //sla.DetermineValidLocations(inputSource) //update to be based on output location?
 			if err != nil {
 				pm.Logger.Error("could not compute locations for this payload")
@@ -321,6 +452,7 @@ func main() {
 				pm.Logger.Error("could not initalize locations for this payload")
 				return
 			}
+			sla.Logger = pm.Logger
 			err = sla.DetermineNormalDensityKernelLocations(a.IOManager) //sla.DetermineValidLocations(inputSource) //update to be based on output location?
 			if err != nil {
 				pm.Logger.Error("could not compute locations for this payload")
@@ -334,6 +466,27 @@ func main() {
 				pm.Logger.Error(err.Error())
 				return
 			}
+		case "generate_full_realization":
+			gfra := actions.InitGenerateFullRealizationAction(a)
+			err = gfra.Compute(pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+		case "build_antecedent_library":
+			bal := actions.InitBuildAntecedentLibraryAction(a)
+			_, err = bal.Compute(pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
+		case "generate_blocks":
+			gba := actions.InitGenerateBlocksAction(a)
+			err = gba.Compute(pm)
+			if err != nil {
+				pm.Logger.Error(err.Error())
+				return
+			}
 		}
 	}
 	if err != nil {
@@ -372,6 +525,179 @@ func getInputBytes(keyword string, extension string, payload cc.Payload, pm *cc.
 	}
 	return returnBytes, errors.New("could not find keyword " + keyword)
 }
+
+// getTerrainConstraint reads the Elevation and Barriers inputs if the action constrains placements by terrain.
+func getTerrainConstraint(a cc.Action, payload cc.Payload, pm *cc.PluginManager) (utils.TerrainConstraint, error) {
+	useElevation, useBarriers := actions.TerrainInputsRequired(a.Attributes)
+	var elevationBytes, barrierBytes []byte
+	var err error
+	if useElevation {
+		elevationBytes, err = getInputBytes("Elevation", "", payload, pm)
+		if err != nil {
+			return utils.TerrainConstraint{}, err
+		}
+	}
+	if useBarriers {
+		barrierBytes, err = getInputBytes("Barriers", "", payload, pm)
+		if err != nil {
+			return utils.TerrainConstraint{}, err
+		}
+	}
+	return actions.TerrainConstraintFromAttributes(a.Attributes, elevationBytes, barrierBytes)
+}
+
+// stochasticTranspositionRun is a single stochastic transposition and the options it is computed with.
+type stochasticTranspositionRun struct {
+	sst                 actions.SingleStochasticTransposition
+	bootstrapCatalog    bool
+	bootstrapOptions    hms.BootstrapOptions
+	normalize           bool
+	userSpecifiedOffset int
+}
+
+// stochasticTransposition reads the hms model, domains and attributes, transposes a storm for the seed set, and fetches the storm dss file.
+// the met time shift is computed relative to the control start time.
+func stochasticTransposition(a cc.Action, payload cc.Payload, pm *cc.PluginManager, seedSet utils.SeedSet, controlStartTime time.Time) (actions.StochasticTranspositionResult, []byte, error) {
+	run, err := initStochasticTransposition(a, payload, pm, seedSet)
+	if err != nil {
+		return actions.StochasticTranspositionResult{}, nil, err
+	}
+	storm, err := run.sst.Transpose(run.bootstrapCatalog, run.bootstrapOptions)
+	if err != nil {
+		return actions.StochasticTranspositionResult{}, nil, errors.New("could not compute payload")
+	}
+	return timeShiftStochasticTransposition(pm, run, storm, controlStartTime)
+}
+
+// initStochasticTransposition reads the inputs and options of a single stochastic transposition.
+func initStochasticTransposition(a cc.Action, payload cc.Payload, pm *cc.PluginManager, seedSet utils.SeedSet) (stochasticTranspositionRun, error) {
+	var output stochasticTranspositionRun
+	gridFileBytes, err := getInputBytes("HMS Model", ".grid", payload, pm)
+	if err != nil {
+		return output, err
+	}
+	metFileBytes, err := getInputBytes("HMS Model", ".met", payload, pm)
+	if err != nil {
+		return output, err
+	}
+	transpositionDomainBytes, err := getInputBytes("TranspositionRegion", "", payload, pm)
+	if err != nil {
+		return output, err
+	}
+	watershedDomainBytes, err := getInputBytes("WatershedBoundary", "", payload, pm)
+	if err != nil {
+		return output, err
+	}
+	gridFile, err := hms.ReadGrid(gridFileBytes)
+	if err != nil {
+		return output, err
+	}
+	metFile, err := hms.ReadMet(metFileBytes)
+	if err != nil {
+		return output, err
+	}
+	var stormWeights utils.StormWeights
+	if a.Attributes.GetBooleanOrDefault("use_storm_weights", false) {
+		stormWeightBytes, err := getInputBytes("StormWeights", "", payload, pm)
+		if err != nil {
+			return output, err
+		}
+		stormWeights, err = utils.StormWeightsFromBytes(stormWeightBytes)
+		if err != nil {
+			return output, err
+		}
+	}
+	companionGridTypes := make([]string, 0)
+	if _, ok := a.Attributes["companion_grid_types"]; ok {
+		companionGridTypes, err = a.Attributes.GetStringSlice("companion_grid_types")
+		if err != nil {
+			return output, err
+		}
+	}
+	placementDensity, err := actions.PlacementDensityFromAttributes(a.Attributes)
+	if err != nil {
+		return output, err
+	}
+	terrain, err := getTerrainConstraint(a, payload, pm)
+	if err != nil {
+		return output, err
+	}
+	sst := actions.InitSingleStochasticTransposition(pm, gridFile, metFile, seedSet, transpositionDomainBytes, watershedDomainBytes, actions.DomainSelectionsFromAttributes(a.Attributes), terrain, stormWeights, companionGridTypes, placementDensity)
+	bootstrapCatalogString := a.Attributes.GetStringOrDefault("bootstrap_catalog", "false")
+	bootstrapCatalog, err := strconv.ParseBool(bootstrapCatalogString)
+	if err != nil {
+		return output, errors.New("could not parse bootstrap_catalog parameter")
+	}
+	bootstrapOptions := hms.BootstrapOptions{
+		Length:              a.Attributes.GetIntOrDefault("bootstrap_catalog_length", len(gridFile.Events)),
+		SubsetLength:        a.Attributes.GetIntOrDefault("bootstrap_subset_length", 0),
+		StratifyByStormType: a.Attributes.GetBooleanOrDefault("bootstrap_by_storm_type", false),
+	}
+	if bootstrapOptions.Length < 1 {
+		return output, errors.New("bootstrap_catalog_length must be at least 1")
+	}
+	if len(gridFile.Events) < bootstrapOptions.SubsetLength {
+		return output, errors.New("cannot allow bootstrap_subset_length to be greater than the catalog length")
+	}
+	normalizeTimeShiftString := a.Attributes.GetStringOrDefault("normalize", "true")
+	normalizeTimeShift, err := strconv.ParseBool(normalizeTimeShiftString)
+	if err != nil {
+		return output, errors.New("could not parse normalize parameter")
+	}
+	userSpecifiedOffset := a.Attributes.GetIntOrDefault("start_time_offset", 0)
+	return stochasticTranspositionRun{sst: sst, bootstrapCatalog: bootstrapCatalog, bootstrapOptions: bootstrapOptions, normalize: normalizeTimeShift, userSpecifiedOffset: userSpecifiedOffset}, nil
+}
+
+// timeShiftStochasticTransposition computes the met time shift of the transposed storm from the control start and reads the storm dss file.
+func timeShiftStochasticTransposition(pm *cc.PluginManager, run stochasticTranspositionRun, storm actions.TransposedStorm, controlStartTime time.Time) (actions.StochasticTranspositionResult, []byte, error) {
+	output, err := run.sst.TimeShift(storm, run.normalize, controlStartTime, run.userSpecifiedOffset)
+	if err != nil {
+		return output, nil, errors.New("could not compute payload")
+	}
+	pm.Logger.Info(fmt.Sprintf("selected storm %v with storm weight %v and unnormalized placement weight %v", output.StormName, output.StormWeight, output.PlacementWeight))
+	dssGridCacheDataSource, err := pm.GetInputDataSource("DSS Grid Cache")
+	if err != nil {
+		return output, nil, errors.New("could not find DSS Grid Cache datasource")
+	}
+	root := dssGridCacheDataSource.Paths["default"]
+	stormName := strings.Replace(output.StormName, "\\", "/", -1)
+	stormDataSource := cc.DataSource{
+		Name:      "DssFile",
+		ID:        &uuid.NameSpaceDNS,
+		Paths:     map[string]string{"default": fmt.Sprintf("%v%v", root, stormName)},
+		StoreName: dssGridCacheDataSource.StoreName,
+	}
+	dssBytes, err := utils.GetFile(*pm, stormDataSource, "default")
+	if err != nil {
+		return output, nil, errors.New("could not find storm")
+	}
+	return output, dssBytes, nil
+}
+
+// putStochasticTransposition writes the storm dss, grid and met files.
+func putStochasticTransposition(output actions.StochasticTranspositionResult, dssBytes []byte, payload cc.Payload, pm *cc.PluginManager) error {
+	err := putOutputBytes(dssBytes, "Storm DSS File", payload, pm)
+	if err != nil {
+		return errors.New("could not put storm")
+	}
+	err = putOutputBytes(output.GridBytes, "Grid File", payload, pm)
+	if err != nil {
+		return errors.New("could not put grid file")
+	}
+	err = putOutputBytes(output.MetBytes, "Met File", payload, pm)
+	if err != nil {
+		return errors.New("could not put met file")
+	}
+	//the storm and placement weights are optional outputs for importance sampled simulations.
+	if _, err = pm.GetOutputDataSource("Event Weights"); err == nil {
+		weights := fmt.Sprintf("storm_path,storm_weight,unnormalized_placement_weight\n%v,%v,%v", output.StormName, output.StormWeight, output.PlacementWeight)
+		err = putOutputBytes([]byte(weights), "Event Weights", payload, pm)
+		if err != nil {
+			return errors.New("could not put event weights")
+		}
+	}
+	return nil
+}
 func putOutputBytes(data []byte, keyword string, payload cc.Payload, pm *cc.PluginManager) error {
 	output, err := pm.GetOutputDataSource(keyword)
 	if err != nil {
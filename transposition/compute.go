@@ -5,6 +5,7 @@ import (
 	"math/rand"
 
 	"github.com/usace-cloud-compute/hms-mutator/hms"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
 )
 
 type TranspositionSimulation struct {
@@ -13,14 +14,14 @@ type TranspositionSimulation struct {
 	gridFile           hms.GridFile
 }
 
-func InitTranspositionSimulation(trgpkgRI []byte, wbgpkgRI []byte, metFile hms.Met, gridFile hms.GridFile) (TranspositionSimulation, error) {
+func InitTranspositionSimulation(trgpkgRI []byte, wbgpkgRI []byte, selections utils.DomainSelections, metFile hms.Met, gridFile hms.GridFile) (TranspositionSimulation, error) {
 	s := TranspositionSimulation{
 		transpositionModel: Model{},
 		metModel:           metFile,
 		gridFile:           gridFile,
 	}
 	//initialize transposition region
-	t, err := InitModel(trgpkgRI, wbgpkgRI) //TODO fix this.
+	t, err := InitModel(trgpkgRI, wbgpkgRI, selections) //TODO fix this.
 	if err != nil {
 		return s, err
 	}
@@ -28,47 +29,113 @@ func InitTranspositionSimulation(trgpkgRI []byte, wbgpkgRI []byte, metFile hms.M
 	return s, nil
 
 }
-func (s *TranspositionSimulation) Compute(eventSeed int64, realizationSeed int64, bootstrapCatalog bool, bootstrapCatalogLength int) (hms.Met, hms.PrecipGridEvent, hms.TempGridEvent, error) {
+
+// SetPlacementDensity importance samples placements from the density.
+func (s *TranspositionSimulation) SetPlacementDensity(density utils.PlacementDensity) error {
+	if err := density.Validate(); err != nil {
+		return err
+	}
+	switch density.Method {
+	case utils.UniformPlacementSampling:
+		return nil
+	case utils.TruncatedNormalPlacementSampling:
+		return s.transpositionModel.SetTruncatedNormalPlacement(density.Center, density.StandardDeviation, density.UniformFraction)
+	default:
+		return fmt.Errorf("%v placement sampling requires a fishnet and is not supported by a single stochastic transposition", density.Method)
+	}
+}
+
+// SetTerrainConstraint rejects placements that fail the terrain constraint.
+func (s *TranspositionSimulation) SetTerrainConstraint(tc utils.TerrainConstraint) error {
+	return s.transpositionModel.SetTerrainConstraint(tc)
+}
+
+// Compute selects and transposes one event, storms are sampled by weight if storm weights are provided.
+// the storm weight and the placement likelihood ratio (one unless placements are importance sampled) are returned.
+func (s *TranspositionSimulation) Compute(eventSeed int64, realizationSeed int64, bootstrapCatalog bool, bootstrapOptions hms.BootstrapOptions, stormWeights utils.StormWeights) (hms.Met, hms.PrecipGridEvent, hms.TempGridEvent, float64, float64, error) {
 	nvrng := rand.New(rand.NewSource(eventSeed))
 	stormSeed := nvrng.Int63()
 	transpositionSeed := nvrng.Int63()
+	var ge hms.PrecipGridEvent
+	var te hms.TempGridEvent
+	var err error
+	catalog := s.gridFile
 	if bootstrapCatalog {
-		//this currently leverages the catalog and bootstraps based on the bootstrap catalog
-		//length to produce a catalog of bootstrap catalog length. Discussions of an Uber catalog
-		//where the catalog will be a superset of arbitrary size, where a subset of fixed
-		//size would be bootstrapped can be completed if the bootstrap catalog length is smaller than the catalog itself.
+		//this leverages the catalog and bootstraps based on the bootstrap options to produce a catalog of
+		//bootstrap length. If the catalog is an Uber catalog (a superset of arbitrary size) the subset length
+		//defines a fixed size subset sampled without replacement that is then bootstrapped.
 		kurng := rand.New(rand.NewSource(realizationSeed))
 		bootstrapSeed := kurng.Int63()
 		//bootstrap catalog
-		s.gridFile.Bootstrap(bootstrapSeed, bootstrapCatalogLength)
+		catalog, err = s.gridFile.BootstrapCatalog(bootstrapSeed, bootstrapOptions)
+		if err != nil {
+			return s.metModel, ge, te, 0, 1, err
+		}
 	}
 
 	//select event
-	ge, te, err := s.gridFile.SelectEvent(stormSeed)
+	placementWeight := 1.0
+	stormWeight := 1.0 / float64(len(catalog.Events))
+	if stormWeights != nil {
+		ge, te, stormWeight, err = selectWeightedEvent(catalog, stormSeed, stormWeights)
+	} else {
+		ge, te, err = catalog.SelectEvent(stormSeed)
+	}
 	if err != nil {
-		return s.metModel, ge, te, err
+		return s.metModel, ge, te, stormWeight, placementWeight, err
 	}
 	//transpose
 	x, y, err := s.transpositionModel.Transpose(transpositionSeed, ge)
 
 	if err != nil {
-		return s.metModel, ge, te, err
+		return s.metModel, ge, te, stormWeight, placementWeight, err
 	}
+	placementWeight = s.transpositionModel.PlacementWeight(x, y)
 
 	fmt.Printf("%v,%f,%f\n", ge.Name, x, y)
 	//update met storm name
 	err = s.metModel.UpdateStormName(ge.Name)
 	if err != nil {
-		return s.metModel, ge, te, err
+		return s.metModel, ge, te, stormWeight, placementWeight, err
+	}
+	//pair the temperature grid, a temperature grid is synthesized if the catalog does not have one for the storm.
+	if s.metModel.HasTemperatureGrid() {
+		if te.Name == "" {
+			te = ge.SynthesizeTemperature()
+		}
+		err = s.metModel.UpdateTempGridName(te.Name)
+		if err != nil {
+			return s.metModel, ge, te, stormWeight, placementWeight, err
+		}
+	} else {
+		te = hms.TempGridEvent{}
 	}
 	//update storm center
 	err = s.metModel.UpdateStormCenter(fmt.Sprintf("%f", x), fmt.Sprintf("%f", y))
 	if err != nil {
-		return s.metModel, ge, te, err
+		return s.metModel, ge, te, stormWeight, placementWeight, err
 	}
 
-	return s.metModel, ge, te, nil
+	return s.metModel, ge, te, stormWeight, placementWeight, nil
 }
-func (s TranspositionSimulation) GetGridFileBytes(precipevent hms.PrecipGridEvent, tempevent hms.TempGridEvent) []byte {
-	return s.gridFile.ToBytes(precipevent, tempevent)
+func (s TranspositionSimulation) GetGridFileBytes(precipevent hms.PrecipGridEvent, tempevent hms.TempGridEvent, companions ...hms.CompanionGridEvent) []byte {
+	return s.gridFile.ToBytes(precipevent, tempevent, companions...)
+}
+
+// selectWeightedEvent selects one event with probability proportional to its storm weight, storms are matched and sampled the same way
+// as full_simulation_sst. Weights are normalized over the events in the catalog (including duplicates from a bootstrap) and the
+// normalized weight of the selected event is returned.
+func selectWeightedEvent(catalog hms.GridFile, seed int64, weights utils.StormWeights) (hms.PrecipGridEvent, hms.TempGridEvent, float64, error) {
+	names := make([]string, len(catalog.Events))
+	for i, e := range catalog.Events {
+		names[i] = e.Name
+	}
+	distribution, err := weights.Distribution(names)
+	if err != nil {
+		return hms.PrecipGridEvent{}, hms.TempGridEvent{}, 0, err
+	}
+	r := rand.New(rand.NewSource(seed))
+	idx := distribution.Sample(r.Float64())
+	pge := catalog.Events[idx]
+	return pge, catalog.PairedTemperature(pge), distribution.Weight(idx), nil
 }
@@ -1,8 +1,8 @@
 package transposition
 
 import (
-	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
 
@@ -10,16 +10,26 @@ import (
 	"github.com/dewberry/gdal"
 
 	"github.com/usace-cloud-compute/hms-mutator/hms"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
 )
 
 type Model struct {
 	//uniform x distribution
 	//uniform y distribution
-	yDist statistics.UniformDistribution
+	yDist statistics.ContinuousDistribution
 	xDist statistics.ContinuousDistribution
+	//the transposition region envelope, placements are weighted relative to uniform sampling of the envelope.
+	xEnvelope statistics.UniformDistribution
+	yEnvelope statistics.UniformDistribution
+	//fraction of placements drawn from the envelope instead of xDist and yDist, so importance sampled weights are bounded.
+	uniformFraction float64
 	//uniform start time distribution
 	transpositionRegionDS gdal.DataSource
 	watershedBoundaryDS   gdal.DataSource
+	//the selected features of each datasource unioned, they may be multipolygons and have holes.
+	transpositionRegions utils.DomainSet //the default region and the regions of each storm type if they are keyed by storm type.
+	watershedBoundary    utils.Domain
+	terrain              utils.TerrainConstraint //placements are not constrained by terrain unless it is set.
 }
 type ModelResult struct {
 	X float64
@@ -27,7 +37,7 @@ type ModelResult struct {
 	//time offset?
 }
 
-func InitModel(transpositionRegion []byte, watershedBoundary []byte) (Model, error) {
+func InitModel(transpositionRegion []byte, watershedBoundary []byte, selections utils.DomainSelections) (Model, error) {
 	model := Model{}
 	localDir := "/app/data/"
 	wfileName := "watershedBoundary.gpkg"
@@ -48,102 +58,118 @@ func InitModel(transpositionRegion []byte, watershedBoundary []byte) (Model, err
 		return model, err
 	}
 	ds := gdal.OpenDataSource(filePath, 0) //defer disposing the datasource and layers.
-	layer := ds.LayerByIndex(0)
-	envelope, err := layer.Extent(true)
-	MaxX := envelope.MaxX()
-	MinX := envelope.MinX()
-	MinY := envelope.MinY()
-	MaxY := envelope.MaxY()
-	x := statistics.UniformDistribution{Max: MaxX, Min: MinX}
-	y := statistics.UniformDistribution{Max: MaxY, Min: MinY}
+	tregions, err := utils.ReadDomainSet(ds, selections.TranspositionRegion)
+	if err != nil {
+		return model, fmt.Errorf("could not read the transposition region: %v", err)
+	}
+	wboundary, err := utils.ReadDomain(wds, selections.WatershedBoundary)
+	if err != nil {
+		return model, fmt.Errorf("could not read the watershed boundary: %v", err)
+	}
+	//the envelope of every selected feature (not the layer), placements for a storm type are rejected outside its region.
+	envelope := tregions.Default.Envelope
+	x := statistics.UniformDistribution{Max: envelope.MaxX, Min: envelope.MinX}
+	y := statistics.UniformDistribution{Max: envelope.MaxY, Min: envelope.MinY}
 	return Model{
 		yDist:                 y,
 		xDist:                 x,
+		xEnvelope:             x,
+		yEnvelope:             y,
 		transpositionRegionDS: ds,
 		watershedBoundaryDS:   wds,
+		transpositionRegions:  tregions,
+		watershedBoundary:     wboundary,
 	}, nil
 }
-func (t Model) Transpose(seed int64, pge hms.PrecipGridEvent) (float64, float64, error) {
-	r := rand.New(rand.NewSource(seed))
-	layer := t.transpositionRegionDS.LayerByIndex(0)
-	//fmt.Printf("transposition Layers %v\n", t.watershedBoundaryDS.LayerCount())
-	//tfc, _ := layer.FeatureCount(true)
-	//fmt.Printf("watershed features in layer 0 %v\n", tfc)
-	//defer layer.Definition().Destroy()
-	transpositionRegion := layer.NextFeature()
-	defer transpositionRegion.Destroy()
-	if transpositionRegion.IsNull() {
-		fmt.Println("im null...")
-	} else {
-		fmt.Println("transposition region is not null")
 
+// SetTruncatedNormalPlacement draws placements from independent normals in x and y around center truncated to the transposition
+// region envelope instead of uniformly over the envelope, uniformFraction of the placements are still drawn uniformly over the envelope
+// like the importance distributions of full_simulation_sst.
+func (t *Model) SetTruncatedNormalPlacement(center utils.Coordinate, standardDeviation float64, uniformFraction float64) error {
+	if math.IsNaN(uniformFraction) || uniformFraction < 0 || uniformFraction > 1 {
+		return fmt.Errorf("the uniform fraction %v must be between 0 and 1", uniformFraction)
+	}
+	x := utils.TruncatedNormal{Mean: center.X, StandardDeviation: standardDeviation, Min: t.xEnvelope.Min, Max: t.xEnvelope.Max}
+	y := utils.TruncatedNormal{Mean: center.Y, StandardDeviation: standardDeviation, Min: t.yEnvelope.Min, Max: t.yEnvelope.Max}
+	if err := x.Validate(); err != nil {
+		return fmt.Errorf("could not sample x placements: %v", err)
 	}
+	if err := y.Validate(); err != nil {
+		return fmt.Errorf("could not sample y placements: %v", err)
+	}
+	t.xDist = x
+	t.yDist = y
+	t.uniformFraction = uniformFraction
+	return nil
+}
 
-	wlayer := t.watershedBoundaryDS.LayerByIndex(0)
-	//fmt.Printf("watershed Layers %v\n", t.watershedBoundaryDS.LayerCount())
-	//fc, _ := wlayer.FeatureCount(true)
-	//fmt.Printf("watershed features in layer 0 %v\n", fc)
-	wf := wlayer.NextFeature()
-	defer wf.Destroy()
-	if wf.Geometry().Type() != 3 {
-		return 0, 0, errors.New("watershed boundary geometry not a simple polygon")
+// SetTerrainConstraint rejects placements that fail the terrain constraint in addition to the geometric test.
+func (t *Model) SetTerrainConstraint(tc utils.TerrainConstraint) error {
+	tc, err := tc.ForWatershed(t.watershedBoundary)
+	if err != nil {
+		return fmt.Errorf("could not constrain placements by terrain: %v", err)
 	}
-	if wf.IsNull() {
-		fmt.Println("im null...")
+	t.terrain = tc
+	return nil
+}
+
+// PlacementWeight is the likelihood ratio of a placement, the uniform density of the envelope divided by the placement density (the
+// mixture of the uniform fraction and xDist and yDist). both densities are restricted to valid placements by the same rejection, so the
+// weight is relative to uniform sampling up to a constant and weights should be normalized by their mean over the events.
+func (t Model) PlacementWeight(x float64, y float64) float64 {
+	uniform := t.xEnvelope.PDF(x) * t.yEnvelope.PDF(y)
+	density := t.uniformFraction*uniform + (1-t.uniformFraction)*t.xDist.PDF(x)*t.yDist.PDF(y)
+	if density <= 0 {
+		return 0
+	}
+	return uniform / density
+}
+
+// maxPlacementDraws limits the number of placements drawn for a storm, a narrow placement density away from the valid placements
+// could otherwise reject every draw.
+const maxPlacementDraws int = 100000
+
+// Transpose samples placements until the placement is in the transposition region of the storm type and the watershed boundary shifted by the
+// inverse of the offset from the storm center is contained by the region, every part of a multipolygon watershed must be
+// contained and holes in the transposition region are excluded. If a terrain constraint is set the placement must also be accepted by it.
+// An error is returned if no placement is valid in maxPlacementDraws draws.
+func (t Model) Transpose(seed int64, pge hms.PrecipGridEvent) (float64, float64, error) {
+	r := rand.New(rand.NewSource(seed))
+	transpositionRegion, err := t.transpositionRegions.ForStormType(pge.StormType())
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not transpose %v: %v", pge.Name, err)
 	}
-	ref := layer.SpatialReference()
-	//defer ref.Destroy()
-	xOffset := 0.0
-	yOffset := 0.0
 	//fmt.Printf("Original Center (%v,%v)\n", pge.CenterX, pge.CenterY)
-	for {
+	for i := 0; i < maxPlacementDraws; i++ {
 		xrand := rand.New(rand.NewSource(r.Int63()))
 		yrand := rand.New(rand.NewSource(r.Int63()))
-		xval := t.xDist.InvCDF(xrand.Float64())
-		yval := t.yDist.InvCDF(yrand.Float64())
+		xDist, yDist := t.xDist, t.yDist
+		if t.uniformFraction > 0 && xrand.Float64() < t.uniformFraction {
+			xDist, yDist = t.xEnvelope, t.yEnvelope
+		}
+		xval := xDist.InvCDF(xrand.Float64())
+		yval := yDist.InvCDF(yrand.Float64())
 
 		//validate if in transposition polygon, iterate until it is
-		newCenter, err := gdal.CreateFromWKT(fmt.Sprintf("Point (%v %v)\n", xval, yval), ref)
-		//defer newCenter.Destroy()
+		contained, err := transpositionRegion.ContainsPoint(utils.Coordinate{X: xval, Y: yval})
 		if err != nil {
 			return xval, yval, err
 		}
-
-		//fmt.Println(geom.Envelope())
-		if transpositionRegion.Geometry().Contains(newCenter) {
-			xOffset = xval - pge.CenterX
-			yOffset = yval - pge.CenterY
-
+		if contained {
+			xOffset := xval - pge.CenterX
+			yOffset := yval - pge.CenterY
 			//fmt.Printf("Offset(x,y): (%v,%v)\n", xOffset, yOffset)
-			shiftContained := false                           //TODO switch to false and test.
-			shiftedWatershedBoundary := wf.Geometry().Clone() //shift watershed boundary
-			//defer shiftedWatershedBoundary.Destroy()
-			//shiftedWatershedBoundary = shiftedWatershedBoundary.Geometry(0)
-			geometrycount := shiftedWatershedBoundary.GeometryCount()
-			//count := shiftedWatershedBoundary.PointCount()
-			//origCount := wf.Geometry().Geometry(0).PointCount()
-
-			for g := 0; g < geometrycount; g++ {
-				geometry := shiftedWatershedBoundary.Geometry(g)
-				defer geometry.Destroy()
-				geometryPointCount := geometry.PointCount()
-				//fmt.Printf("geometry point count %v\n", geometryPointCount)
-				for i := 0; i < geometryPointCount; i++ {
-					px, py, pz := geometry.Point(i)
-					shiftedWatershedBoundary.Geometry(g).SetPoint(i, px-xOffset, py-yOffset, pz) //does this work or does it insert?
-				}
-			}
-
+			shiftedWatershedBoundary := t.watershedBoundary.Shifted(-xOffset, -yOffset)
 			//check shifted watershed boundary is contained in transposition region
-			shiftContained = transpositionRegion.Geometry().Contains(shiftedWatershedBoundary)
-			if shiftContained {
-				//s, _ := shiftedWatershedBoundary.ToWKT()
-				//fmt.Println(s)
+			shiftContained := transpositionRegion.Geometry.Contains(shiftedWatershedBoundary)
+			shiftedWatershedBoundary.Destroy()
+			if shiftContained && t.terrain.Accept(utils.Coordinate{X: pge.CenterX, Y: pge.CenterY}, utils.Coordinate{X: xval, Y: yval}) {
 				//return pge.CenterX, pge.CenterY, nil //for debugging issues with time offsets and to avoid confusion created by different centerings.
 				return xval, yval, nil
 			}
 		}
 	}
+	return 0, 0, fmt.Errorf("could not find a valid placement for %v in %v draws", pge.Name, maxPlacementDraws)
 }
 func writeLocalBytes(b []byte, destinationRoot string, destinationPath string) error {
 	if _, err := os.Stat(destinationRoot); os.IsNotExist(err) {
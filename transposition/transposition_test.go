@@ -3,10 +3,13 @@ package transposition
 import (
 	"fmt"
 	"io/ioutil"
+	"math"
 	"math/rand"
 	"testing"
 
+	"github.com/HydrologicEngineeringCenter/go-statistics/statistics"
 	"github.com/usace-cloud-compute/hms-mutator/hms"
+	"github.com/usace-cloud-compute/hms-mutator/utils"
 )
 
 func TestInitTransposition(t *testing.T) {
@@ -15,7 +18,7 @@ func TestInitTransposition(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	tr, err := InitModel(bytes, bytes)
+	tr, err := InitModel(bytes, bytes, utils.DomainSelections{})
 	if err != nil {
 		fmt.Println(err)
 		t.Fail()
@@ -33,7 +36,7 @@ func TestSampleLocations(t *testing.T) {
 	if err != nil {
 		t.Fail()
 	}
-	tr, err := InitModel(tbytes, wbytes)
+	tr, err := InitModel(tbytes, wbytes, utils.DomainSelections{})
 	if err != nil {
 		fmt.Println(err)
 		t.Fail()
@@ -86,13 +89,13 @@ func TestSimulationCompute(t *testing.T) {
 	//controlFile, err := hms.ReadControl(cbytes)
 
 	//initialize simulation
-	sim, err := InitTranspositionSimulation(tbytes, wbytes, metFile, gridFile)
+	sim, err := InitTranspositionSimulation(tbytes, wbytes, utils.DomainSelections{}, metFile, gridFile)
 	if err != nil {
 		fmt.Println(err)
 		t.Fail()
 	} else {
 		//compute simulation for given seed set
-		m, ge, _, err := sim.Compute(1234, 4321, true, len(gridFile.Events))
+		m, ge, _, _, _, err := sim.Compute(1234, 4321, true, hms.BootstrapOptions{Length: len(gridFile.Events)}, nil)
 		if err != nil {
 			fmt.Println(err)
 			t.Fail()
@@ -128,3 +131,65 @@ func Test_EventConfiguration(t *testing.T) {
 		t.Fail()
 	}
 }*/
+
+func TestSelectWeightedEvent(t *testing.T) {
+	catalog := hms.GridFile{Events: []hms.PrecipGridEvent{{Name: "AORC 1979-02-05"}, {Name: "AORC 1980-03-06.dss"}}}
+	//the second storm is matched by its name without the extension.
+	weights := utils.StormWeights{"AORC 1979-02-05": 3, "AORC 1980-03-06": 1}
+	rnd := rand.New(rand.NewSource(1234))
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		e, _, w, err := selectWeightedEvent(catalog, rnd.Int63(), weights)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := weights.Weight(e.Name)
+		if w != expected/4 {
+			t.Errorf("expected normalized weight %v for %v got %v", expected/4, e.Name, w)
+		}
+		counts[e.Name]++
+	}
+	fraction := float64(counts["AORC 1979-02-05"]) / 10000
+	if math.Abs(fraction-.75) > .02 {
+		t.Errorf("expected about 75 percent of selections to be the heavier storm, got %v", fraction)
+	}
+	weights["AORC 1979-02-05"] = 0
+	for i := 0; i < 100; i++ {
+		e, _, _, _ := selectWeightedEvent(catalog, rnd.Int63(), weights)
+		if e.Name != "AORC 1980-03-06.dss" {
+			t.Errorf("selected zero weighted storm %v", e.Name)
+		}
+	}
+	weights["AORC 1980-03-06"] = 0
+	if _, _, _, err := selectWeightedEvent(catalog, rnd.Int63(), weights); err == nil {
+		t.Error("expected an error when every weight is zero")
+	}
+	delete(weights, "AORC 1979-02-05")
+	if _, _, _, err := selectWeightedEvent(catalog, rnd.Int63(), weights); err == nil {
+		t.Error("expected an error for a storm without a weight")
+	}
+}
+func TestPlacementWeight(t *testing.T) {
+	x := statistics.UniformDistribution{Min: 0, Max: 100}
+	y := statistics.UniformDistribution{Min: 0, Max: 100}
+	m := Model{xDist: x, yDist: y, xEnvelope: x, yEnvelope: y}
+	if err := m.SetTruncatedNormalPlacement(utils.Coordinate{X: 50, Y: 50}, 5, .1); err != nil {
+		t.Fatal(err)
+	}
+	//the uniform fraction bounds the weight of placements in the tails of the normal.
+	if w := m.PlacementWeight(1, 1); w > 10+1e-9 || w < 9.9 {
+		t.Errorf("expected a tail weight just under 1/.1, got %v", w)
+	}
+	if w := m.PlacementWeight(50, 50); w >= 1 {
+		t.Errorf("expected a weight below one at the center, got %v", w)
+	}
+	if err := m.SetTruncatedNormalPlacement(utils.Coordinate{X: 50, Y: 50}, 5, 1); err != nil {
+		t.Fatal(err)
+	}
+	if w := m.PlacementWeight(1, 1); math.Abs(w-1) > 1e-12 {
+		t.Errorf("expected a weight of one when every placement is uniform, got %v", w)
+	}
+	if err := m.SetTruncatedNormalPlacement(utils.Coordinate{X: 50, Y: 50}, 5, 1.5); err == nil {
+		t.Error("expected an error for a uniform fraction greater than one")
+	}
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AntecedentBasinDateFormat is the date format used in antecedent condition basin names (yyyy-mm-dd_basin-name_calibration-event).
+const AntecedentBasinDateFormat string = "2006-01-02"
+
+// AntecedentBasin identifies a basin file that represents the antecedent conditions on a date for a calibration event parameterization.
+type AntecedentBasin struct {
+	Date             time.Time
+	BasinName        string
+	CalibrationEvent string
+}
+
+// Name is the basin name without an extension.
+func (ab AntecedentBasin) Name() string {
+	return fmt.Sprintf("%v_%v_%v", ab.Date.Format(AntecedentBasinDateFormat), ab.BasinName, ab.CalibrationEvent)
+}
+
+// Path is the basin path relative to the root directory without an extension.
+func (ab AntecedentBasin) Path(root string) string {
+	return fmt.Sprintf("%v/%v", root, ab.Name())
+}
+
+// SampleCalibrationEvent selects a calibration event with uniform probability.
+func SampleCalibrationEvent(rng *rand.Rand, calibrationEventNames []string) string {
+	return calibrationEventNames[rng.Intn(len(calibrationEventNames))]
+}
+
+// ParseAntecedentBasin parses a basin file name, the basin name is required because basin and calibration event names may contain underscores.
+func ParseAntecedentBasin(fileName string, basinName string) (AntecedentBasin, error) {
+	name := strings.TrimSuffix(path.Base(fileName), path.Ext(fileName))
+	parts := strings.SplitN(name, "_", 2)
+	if len(parts) != 2 {
+		return AntecedentBasin{}, fmt.Errorf("%v is not named yyyy-mm-dd_basin-name_calibration-event", fileName)
+	}
+	date, err := time.Parse(AntecedentBasinDateFormat, parts[0])
+	if err != nil {
+		return AntecedentBasin{}, fmt.Errorf("%v is not named yyyy-mm-dd_basin-name_calibration-event", fileName)
+	}
+	prefix := basinName + "_"
+	if !strings.HasPrefix(parts[1], prefix) || len(parts[1]) == len(prefix) {
+		return AntecedentBasin{}, fmt.Errorf("%v is not a %v basin", fileName, basinName)
+	}
+	return AntecedentBasin{Date: date, BasinName: basinName, CalibrationEvent: strings.TrimPrefix(parts[1], prefix)}, nil
+}
+
+// ParseAntecedentBasins parses the basins for a basin name from a list of file names, files that do not follow the convention are skipped.
+func ParseAntecedentBasins(fileNames []string, basinName string) []AntecedentBasin {
+	basins := make([]AntecedentBasin, 0, len(fileNames))
+	for _, f := range fileNames {
+		b, err := ParseAntecedentBasin(f, basinName)
+		if err == nil {
+			basins = append(basins, b)
+		}
+	}
+	return basins
+}
+
+// NearestAntecedentBasin finds the basin for the calibration event whose date is closest to the storm date. If matchSeason is true
+// the distance is measured in days of the year (ignoring the year) so the basin from the closest season is selected.
+// Ties are broken by the closest absolute date and then the earlier date.
+func NearestAntecedentBasin(basins []AntecedentBasin, stormDate time.Time, calibrationEvent string, matchSeason bool) (AntecedentBasin, error) {
+	found := false
+	var nearest AntecedentBasin
+	bestSeason, bestAbsolute := math.MaxFloat64, math.MaxFloat64
+	for _, b := range basins {
+		if b.CalibrationEvent != calibrationEvent {
+			continue
+		}
+		absolute := math.Abs(b.Date.Sub(stormDate).Hours())
+		season := 0.0
+		if matchSeason {
+			season = seasonalDistance(b.Date, stormDate)
+		}
+		better := season < bestSeason || (season == bestSeason && absolute < bestAbsolute) || (season == bestSeason && absolute == bestAbsolute && b.Date.Before(nearest.Date))
+		if !found || better {
+			found = true
+			nearest = b
+			bestSeason, bestAbsolute = season, absolute
+		}
+	}
+	if !found {
+		return nearest, fmt.Errorf("no basins were found for calibration event %v", calibrationEvent)
+	}
+	return nearest, nil
+}
+
+// seasonalDistance is the circular distance in days between the days of the year of two dates.
+func seasonalDistance(a time.Time, b time.Time) float64 {
+	d := math.Abs(float64(a.YearDay() - b.YearDay()))
+	return math.Min(d, 365-d)
+}
+
+// InitialCondition is the value of a basin element parameter (for example the initial deficit of a subbasin) on a date.
+type InitialCondition struct {
+	Element   string
+	Parameter string
+	Value     string
+}
+
+// InitialConditionTable is a set of initial conditions keyed by date (yyyy-mm-dd).
+type InitialConditionTable map[string][]InitialCondition
+
+// InitialConditionsFromBytes reads a csv with a header and date,element,parameter,value rows, dates are yyyy-mm-dd or yyyymmdd.
+func InitialConditionsFromBytes(data []byte) (InitialConditionTable, error) {
+	table := make(InitialConditionTable)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if i == 0 || len(strings.TrimSpace(line)) == 0 {
+			continue //skip header and empty lines
+		}
+		vals := strings.Split(line, ",")
+		if len(vals) < 4 {
+			return table, fmt.Errorf("initial conditions line %v does not have a date, element, parameter, and value", i+1)
+		}
+		dateString := strings.TrimSpace(vals[0])
+		date, err := time.Parse(AntecedentBasinDateFormat, dateString)
+		if err != nil {
+			date, err = time.Parse("20060102", dateString)
+			if err != nil {
+				return table, fmt.Errorf("could not parse the date on initial conditions line %v: %v", i+1, dateString)
+			}
+		}
+		ic := InitialCondition{
+			Element:   strings.TrimSpace(vals[1]),
+			Parameter: strings.TrimSpace(vals[2]),
+			Value:     strings.TrimSpace(strings.Join(vals[3:], ",")),
+		}
+		if ic.Element == "" || ic.Parameter == "" || ic.Value == "" {
+			return table, fmt.Errorf("initial conditions line %v has an empty element, parameter, or value", i+1)
+		}
+		key := date.Format(AntecedentBasinDateFormat)
+		table[key] = append(table[key], ic)
+	}
+	if len(table) == 0 {
+		return table, fmt.Errorf("no initial conditions were found")
+	}
+	return table, nil
+}
+
+// Dates returns the dates in the table in order.
+func (t InitialConditionTable) Dates() []time.Time {
+	dates := make([]time.Time, 0, len(t))
+	for k := range t {
+		date, _ := time.Parse(AntecedentBasinDateFormat, k)
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// MissingDates returns the days from porStart to porEnd (inclusive) that do not have initial conditions.
+func (t InitialConditionTable) MissingDates(porStart time.Time, porEnd time.Time) []time.Time {
+	missing := make([]time.Time, 0)
+	start := time.Date(porStart.Year(), porStart.Month(), porStart.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(porEnd.Year(), porEnd.Month(), porEnd.Day(), 0, 0, 0, 0, time.UTC)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if _, ok := t[d.Format(AntecedentBasinDateFormat)]; !ok {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+// AntecedentLibraryIndex lists the basins in an antecedent condition library.
+type AntecedentLibraryIndex []AntecedentBasin
+
+// ToBytes writes a csv with date,calibration_event,basin_path rows, the basin path matches the basin paths sampled by full_simulation_sst.
+func (ali AntecedentLibraryIndex) ToBytes(root string) []byte {
+	var data strings.Builder
+	data.WriteString("date,calibration_event,basin_path")
+	for _, b := range ali {
+		fmt.Fprintf(&data, "\n%v,%v,%v", b.Date.Format(AntecedentBasinDateFormat), b.CalibrationEvent, b.Path(root))
+	}
+	return []byte(data.String())
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNearestAntecedentBasin(t *testing.T) {
+	files := []string{
+		"data/basinmodels/1990-04-01_trinity_apr_may_1990.basin",
+		"data/basinmodels/1990-04-20_trinity_apr_may_1990.basin",
+		"data/basinmodels/2015-12-28_trinity_apr_may_1990.basin",
+		"data/basinmodels/1990-04-12_trinity_dec_1991.basin",
+		"data/basinmodels/1990-04-12_other_apr_may_1990.basin",
+		"data/basinmodels/readme.basin",
+	}
+	basins := ParseAntecedentBasins(files, "trinity")
+	if len(basins) != 4 {
+		t.Fatalf("expected 4 trinity basins got %v", len(basins))
+	}
+	if basins[0].CalibrationEvent != "apr_may_1990" || basins[0].Name() != "1990-04-01_trinity_apr_may_1990" {
+		t.Errorf("unexpected basin %v", basins[0].Name())
+	}
+	stormDate := time.Date(1990, 4, 14, 0, 0, 0, 0, time.UTC)
+	b, err := NearestAntecedentBasin(basins, stormDate, "apr_may_1990", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name() != "1990-04-20_trinity_apr_may_1990" {
+		t.Errorf("expected the closest date got %v", b.Name())
+	}
+	b, err = NearestAntecedentBasin(basins, time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC), "apr_may_1990", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name() != "2015-12-28_trinity_apr_may_1990" {
+		t.Errorf("expected the closest season got %v", b.Name())
+	}
+	_, err = NearestAntecedentBasin(basins, stormDate, "oct_nov_2015", false)
+	if err == nil {
+		t.Error("expected an error for a calibration event without basins")
+	}
+	if p := b.Path("data/basinmodels"); p != "data/basinmodels/2015-12-28_trinity_apr_may_1990" {
+		t.Errorf("unexpected path %v", p)
+	}
+}
+func TestInitialConditionsFromBytes(t *testing.T) {
+	table, err := InitialConditionsFromBytes([]byte("date,element,parameter,value\r\n1990-01-02,Subbasin-1,Initial Deficit,1.5\r\n19900102,Subbasin-2,Initial Deficit,0.5\r\n1990-01-04,Subbasin-1,Initial Deficit,2\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table["1990-01-02"]) != 2 {
+		t.Errorf("expected both date formats to be keyed to the same day got %v", table)
+	}
+	dates := table.Dates()
+	if len(dates) != 2 || !dates[0].Before(dates[1]) {
+		t.Errorf("expected 2 ordered dates got %v", dates)
+	}
+	missing := table.MissingDates(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1990, 1, 4, 0, 0, 0, 0, time.UTC))
+	if len(missing) != 2 || missing[0].Day() != 1 || missing[1].Day() != 3 {
+		t.Errorf("expected january 1 and 3 to be missing got %v", missing)
+	}
+	_, err = InitialConditionsFromBytes([]byte("date,element,parameter,value\n1990-01-02,Subbasin-1,,1\n"))
+	if err == nil {
+		t.Error("expected an error for an empty parameter")
+	}
+}
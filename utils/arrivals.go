@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+)
+
+const (
+	PoissonArrivals          string = "poisson"
+	NegativeBinomialArrivals string = "negative_binomial"
+)
+
+// ArrivalRate describes the annual (per block) count of events for a storm type.
+// Variance is only used by the negative binomial and must be greater than the mean.
+type ArrivalRate struct {
+	StormType    string
+	Distribution string
+	Mean         float64
+	Variance     float64
+}
+
+// ArrivalModel is a set of arrival rates by storm type, the block event count is the sum of the counts of each storm type.
+type ArrivalModel []ArrivalRate
+
+// ArrivalModelFromAttributes reads a map of storm type to arrival rate definitions, for example
+// {"ST1": {"distribution": "poisson", "mean": 1.5}, "ST2": {"distribution": "negative_binomial", "mean": 0.8, "variance": 1.2}}
+func ArrivalModelFromAttributes(rates map[string]any) (ArrivalModel, error) {
+	model := make(ArrivalModel, 0)
+	stormTypes := make([]string, 0)
+	for st := range rates {
+		stormTypes = append(stormTypes, st)
+	}
+	sort.Strings(stormTypes) //map order is random, keep the sampling order stable.
+	for _, st := range stormTypes {
+		definition, ok := rates[st].(map[string]any)
+		if !ok {
+			return model, fmt.Errorf("arrival rate for storm type %v is not an object", st)
+		}
+		attrs := cc.PayloadAttributes(definition)
+		rate := ArrivalRate{
+			StormType:    st,
+			Distribution: attrs.GetStringOrDefault("distribution", PoissonArrivals),
+		}
+		mean, err := attrs.GetFloat("mean")
+		if err != nil {
+			return model, fmt.Errorf("arrival rate for storm type %v requires a mean", st)
+		}
+		rate.Mean = mean
+		if rate.Distribution == NegativeBinomialArrivals {
+			variance, err := attrs.GetFloat("variance")
+			if err != nil {
+				return model, fmt.Errorf("negative binomial arrival rate for storm type %v requires a variance", st)
+			}
+			rate.Variance = variance
+		}
+		model = append(model, rate)
+	}
+	return model, model.Validate()
+}
+func (am ArrivalModel) Validate() error {
+	if len(am) == 0 {
+		return fmt.Errorf("the arrival model has no arrival rates")
+	}
+	for _, r := range am {
+		if math.IsNaN(r.Mean) || math.IsInf(r.Mean, 0) || r.Mean < 0 {
+			return fmt.Errorf("storm type %v has an invalid mean arrival rate %v", r.StormType, r.Mean)
+		}
+		switch r.Distribution {
+		case PoissonArrivals:
+		case NegativeBinomialArrivals:
+			if !(r.Variance > r.Mean) {
+				return fmt.Errorf("storm type %v has a negative binomial variance %v that is not greater than the mean %v", r.StormType, r.Variance, r.Mean)
+			}
+		default:
+			return fmt.Errorf("storm type %v has an unsupported arrival distribution %v", r.StormType, r.Distribution)
+		}
+	}
+	return nil
+}
+
+// Sample draws an event count for one block.
+func (r ArrivalRate) Sample(rng *rand.Rand) int {
+	switch r.Distribution {
+	case NegativeBinomialArrivals:
+		//gamma mixture of poissons with shape mean^2/(variance-mean) and scale (variance-mean)/mean
+		shape := r.Mean * r.Mean / (r.Variance - r.Mean)
+		scale := (r.Variance - r.Mean) / r.Mean
+		return samplePoisson(rng, sampleGamma(rng, shape)*scale)
+	default:
+		return samplePoisson(rng, r.Mean)
+	}
+}
+
+// Sample draws event counts for one block by storm type, counts are ordered consistently with the model.
+func (am ArrivalModel) Sample(rng *rand.Rand) []int {
+	counts := make([]int, len(am))
+	for i, r := range am {
+		counts[i] = r.Sample(rng)
+	}
+	return counts
+}
+
+// GenerateBlocks creates blocksPerRealization blocks for each realization, one realization per seed.
+// realization, block, and event numbers start at 1 and event numbers are continuous across realizations.
+// the event count of each storm type in each block is also returned, in model order within a block.
+func GenerateBlocks(model ArrivalModel, realizationBlockSeeds []int64, blocksPerRealization int) ([]Block, []BlockStormTypeCount, error) {
+	blocks := make([]Block, 0, len(realizationBlockSeeds)*blocksPerRealization)
+	typeCounts := make([]BlockStormTypeCount, 0, len(realizationBlockSeeds)*blocksPerRealization*len(model))
+	if blocksPerRealization < 1 {
+		return blocks, typeCounts, fmt.Errorf("blocks per realization must be at least 1")
+	}
+	err := model.Validate()
+	if err != nil {
+		return blocks, typeCounts, err
+	}
+	var lastEvent int64 = 0
+	for r, seed := range realizationBlockSeeds {
+		rng := rand.New(rand.NewSource(seed))
+		for b := 0; b < blocksPerRealization; b++ {
+			counts := model.Sample(rng)
+			count := 0
+			for i, c := range counts {
+				count += c
+				typeCounts = append(typeCounts, BlockStormTypeCount{RealizationIndex: int32(r + 1), BlockIndex: int32(b + 1), StormType: model[i].StormType, EventCount: int32(c)})
+			}
+			block := Block{
+				RealizationIndex: int32(r + 1),
+				BlockIndex:       int32(b + 1),
+				BlockEventCount:  int32(count),
+				BlockEventStart:  lastEvent + 1,
+				BlockEventEnd:    lastEvent + int64(count),
+			}
+			lastEvent = block.BlockEventEnd
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, typeCounts, nil
+}
+
+// maxPoissonMean limits the mean sampled by inversion to avoid underflow of exp(-mean), larger means are sampled as a sum of poissons.
+const maxPoissonMean float64 = 500
+
+func samplePoisson(rng *rand.Rand, mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	count := 0
+	for mean > maxPoissonMean {
+		count += samplePoisson(rng, maxPoissonMean)
+		mean -= maxPoissonMean
+	}
+	//inversion by sequential search
+	u := rng.Float64()
+	k := 0
+	p := math.Exp(-mean)
+	cumulative := p
+	for u > cumulative {
+		k++
+		p = p * mean / float64(k)
+		cumulative += p
+		if p == 0 {
+			break //round off in the tail
+		}
+	}
+	return count + k
+}
+
+// sampleGamma samples a unit scale gamma with the Marsaglia and Tsang method.
+func sampleGamma(rng *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		//boost the shape and correct with a uniform power.
+		return sampleGamma(rng, shape+1) * math.Pow(rng.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3.0
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
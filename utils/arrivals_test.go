@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func sampleMoments(rate ArrivalRate, n int, seed int64) (float64, float64) {
+	rng := rand.New(rand.NewSource(seed))
+	sum := 0.0
+	sumsq := 0.0
+	for i := 0; i < n; i++ {
+		c := float64(rate.Sample(rng))
+		sum += c
+		sumsq += c * c
+	}
+	mean := sum / float64(n)
+	return mean, sumsq/float64(n) - mean*mean
+}
+func TestArrivalRateMoments(t *testing.T) {
+	rates := []ArrivalRate{
+		{StormType: "ST1", Distribution: PoissonArrivals, Mean: 2.5},
+		{StormType: "ST2", Distribution: PoissonArrivals, Mean: 750},
+		{StormType: "ST3", Distribution: NegativeBinomialArrivals, Mean: 1.5, Variance: 4},
+		{StormType: "ST4", Distribution: NegativeBinomialArrivals, Mean: 0.3, Variance: 0.5},
+	}
+	for _, r := range rates {
+		mean, variance := sampleMoments(r, 200000, 1234)
+		expectedVariance := r.Mean
+		if r.Distribution == NegativeBinomialArrivals {
+			expectedVariance = r.Variance
+		}
+		if math.Abs(mean-r.Mean) > .02*r.Mean+.01 {
+			t.Errorf("%v expected mean %v got %v", r.StormType, r.Mean, mean)
+		}
+		if math.Abs(variance-expectedVariance) > .05*expectedVariance+.01 {
+			t.Errorf("%v expected variance %v got %v", r.StormType, expectedVariance, variance)
+		}
+	}
+}
+func TestGenerateBlocks(t *testing.T) {
+	model, err := ArrivalModelFromAttributes(map[string]any{
+		"ST1": map[string]any{"distribution": "poisson", "mean": 1.2},
+		"ST2": map[string]any{"distribution": "negative_binomial", "mean": 0.4, "variance": 0.9},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, typeCounts, err := GenerateBlocks(model, []int64{1234, 5678, 91011}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 150 {
+		t.Fatalf("expected 150 blocks got %v", len(blocks))
+	}
+	var lastEvent int64 = 0
+	total := 0
+	for _, b := range blocks {
+		if b.BlockEventStart != lastEvent+1 || b.BlockEventEnd-b.BlockEventStart+1 != int64(b.BlockEventCount) {
+			t.Errorf("block %v of realization %v is not continuous with the previous block", b.BlockIndex, b.RealizationIndex)
+		}
+		lastEvent = b.BlockEventEnd
+		total += int(b.BlockEventCount)
+	}
+	totals := StormTypeTotals(typeCounts)
+	if total != totals["ST1"]+totals["ST2"] {
+		t.Errorf("storm type totals %v do not sum to the block totals %v", totals, total)
+	}
+	eventTypes, err := EventStormTypes(blocks, typeCounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eventTypes) != total {
+		t.Errorf("expected a storm type for each of the %v events, got %v", total, len(eventTypes))
+	}
+	read, err := BlockStormTypeCountsFromBytes(BlockStormTypeCountsToBytes(typeCounts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range typeCounts {
+		if read[i] != typeCounts[i] {
+			t.Fatalf("expected %v after a csv round trip got %v", typeCounts[i], read[i])
+		}
+	}
+	typeCounts[0].EventCount++
+	if _, err = EventStormTypes(blocks, typeCounts); err == nil {
+		t.Error("expected an error when the storm type counts do not sum to the block event count")
+	}
+	again, _, _ := GenerateBlocks(model, []int64{1234, 5678, 91011}, 50)
+	for i := range blocks {
+		if blocks[i] != again[i] {
+			t.Fatal("block generation is not reproducible for the same seeds")
+		}
+	}
+	_, err = ArrivalModelFromAttributes(map[string]any{"ST1": map[string]any{"distribution": "negative_binomial", "mean": 1.0, "variance": 0.5}})
+	if err == nil {
+		t.Error("expected an error for an underdispersed negative binomial")
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	filestore "github.com/usace-cloud-compute/filesapi"
+)
+
+// BlockStormTypeCount is the number of events of a storm type sampled for a block by the arrival model.
+type BlockStormTypeCount struct {
+	RealizationIndex int32
+	BlockIndex       int32
+	StormType        string
+	EventCount       int32
+}
+
+// StormTypeTotals sums the event counts of each storm type.
+func StormTypeTotals(counts []BlockStormTypeCount) map[string]int {
+	totals := make(map[string]int)
+	for _, c := range counts {
+		totals[c.StormType] += int(c.EventCount)
+	}
+	return totals
+}
+
+// EventStormTypes assigns a storm type to every event of the blocks, the events of a block are assigned to its storm types in the order
+// of the counts (the order of the arrival model). The counts of each block must sum to the block event count.
+func EventStormTypes(blocks []Block, counts []BlockStormTypeCount) (map[int64]string, error) {
+	type blockKey struct {
+		realization int32
+		block       int32
+	}
+	byBlock := make(map[blockKey][]BlockStormTypeCount)
+	for _, c := range counts {
+		key := blockKey{c.RealizationIndex, c.BlockIndex}
+		byBlock[key] = append(byBlock[key], c)
+	}
+	eventTypes := make(map[int64]string)
+	for _, b := range blocks {
+		event := b.BlockEventStart
+		for _, c := range byBlock[blockKey{b.RealizationIndex, b.BlockIndex}] {
+			for i := int32(0); i < c.EventCount; i++ {
+				eventTypes[event] = c.StormType
+				event++
+			}
+		}
+		if event-b.BlockEventStart != int64(b.BlockEventCount) {
+			return eventTypes, fmt.Errorf("the storm type counts of block %v of realization %v sum to %v events but the block has %v events", b.BlockIndex, b.RealizationIndex, event-b.BlockEventStart, b.BlockEventCount)
+		}
+	}
+	return eventTypes, nil
+}
+
+// BlockStormTypeCountsToBytes writes a csv with a header and realization_index,block_index,storm_type,event_count rows.
+func BlockStormTypeCountsToBytes(counts []BlockStormTypeCount) []byte {
+	var data strings.Builder
+	data.WriteString("realization_index,block_index,storm_type,event_count")
+	for _, c := range counts {
+		fmt.Fprintf(&data, "\n%v,%v,%v,%v", c.RealizationIndex, c.BlockIndex, c.StormType, c.EventCount)
+	}
+	return []byte(data.String())
+}
+
+// BlockStormTypeCountsFromBytes reads a csv with a header and realization_index,block_index,storm_type,event_count rows.
+func BlockStormTypeCountsFromBytes(data []byte) ([]BlockStormTypeCount, error) {
+	counts := make([]BlockStormTypeCount, 0)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if i == 0 || len(strings.TrimSpace(line)) == 0 {
+			continue //skip header and empty lines
+		}
+		vals := strings.Split(line, ",")
+		if len(vals) < 4 {
+			return counts, fmt.Errorf("block storm types line %v does not have a realization, block, storm type and event count", i+1)
+		}
+		realization, err := strconv.ParseInt(strings.TrimSpace(vals[0]), 10, 32)
+		if err != nil {
+			return counts, fmt.Errorf("could not parse the realization index on line %v: %v", i+1, err)
+		}
+		block, err := strconv.ParseInt(strings.TrimSpace(vals[1]), 10, 32)
+		if err != nil {
+			return counts, fmt.Errorf("could not parse the block index on line %v: %v", i+1, err)
+		}
+		count, err := strconv.ParseInt(strings.TrimSpace(vals[3]), 10, 32)
+		if err != nil || count < 0 {
+			return counts, fmt.Errorf("line %v does not have a non-negative event count", i+1)
+		}
+		counts = append(counts, BlockStormTypeCount{RealizationIndex: int32(realization), BlockIndex: int32(block), StormType: strings.TrimSpace(vals[2]), EventCount: int32(count)})
+	}
+	return counts, nil
+}
+
+func ReadBlockStormTypeCounts(iomanager cc.IOManager, storeKey string, filePath string) ([]BlockStormTypeCount, error) {
+	store, err := iomanager.GetStore(storeKey)
+	if err != nil {
+		return nil, err
+	}
+	session, ok := store.Session.(*cc.FileDataStore[filestore.S3FS])
+	if !ok {
+		return nil, fmt.Errorf("%v was not an s3datastore type", storeKey)
+	}
+	root := store.Parameters.GetStringOrFail("root")
+	pathpart := strings.Replace(filePath, fmt.Sprintf("%v/", root), "", -1)
+	reader, err := session.Get(pathpart, "")
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return BlockStormTypeCountsFromBytes(bytes)
+}
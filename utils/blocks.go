@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 
@@ -57,6 +58,22 @@ func GetBlocks(pm *cc.PluginManager, a cc.Action) ([]Block, error) {
 	//return blocks, err
 }
 
+// PutBlocks writes blocks to the output datasource named by outputKey in the format GetBlocks reads, a tiledb recordset if use_tile_db is true otherwise json.
+func PutBlocks(pm *cc.PluginManager, a cc.Action, outputKey string, blocks []Block) error {
+	useTileDb := a.Attributes.GetBooleanOrDefault(useTileDbStore, false)
+	blocksOutput, err := a.GetOutputDataSource(outputKey)
+	if err != nil {
+		return err
+	}
+	if useTileDb {
+		blockWriter := NewTileDbBlockWriter(pm, blocksOutput.StoreName, blocksOutput.Name)
+		return blockWriter.Write(blocks)
+	} else {
+		blockWriter := NewJsonBlockWriter(a.IOManager, blocksOutput.Name)
+		return blockWriter.Write(blocks)
+	}
+}
+
 type JsonBlockReader struct {
 	reader io.ReadCloser
 }
@@ -77,6 +94,30 @@ func (jr *JsonBlockReader) Close() {
 	jr.reader.Close()
 }
 
+type JsonBlockWriter struct {
+	iomanager      cc.IOManager
+	datasourceName string
+}
+
+func NewJsonBlockWriter(iomanager cc.IOManager, datasourceName string) *JsonBlockWriter {
+	return &JsonBlockWriter{
+		iomanager:      iomanager,
+		datasourceName: datasourceName,
+	}
+}
+
+func (jw *JsonBlockWriter) Write(blocks []Block) error {
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		return err
+	}
+	_, err = jw.iomanager.Put(cc.PutOpInput{
+		SrcReader:         bytes.NewReader(data),
+		DataSourceOpInput: cc.DataSourceOpInput{DataSourceName: jw.datasourceName, PathKey: jsonBlocksPathKey},
+	})
+	return err
+}
+
 type TileDbBlockReader struct {
 	pm          *cc.PluginManager
 	storeName   string
@@ -112,3 +153,29 @@ func (tr *TileDbBlockReader) Read() ([]Block, error) {
 	}
 	return blocks, nil
 }
+
+type TileDbBlockWriter struct {
+	pm          *cc.PluginManager
+	storeName   string
+	datasetName string
+}
+
+func NewTileDbBlockWriter(pm *cc.PluginManager, tileDbStoreName string, datasetName string) *TileDbBlockWriter {
+	return &TileDbBlockWriter{
+		pm:          pm,
+		storeName:   tileDbStoreName,
+		datasetName: datasetName,
+	}
+}
+
+func (tw *TileDbBlockWriter) Write(blocks []Block) error {
+	recordset, err := cc.NewEventStoreRecordset(tw.pm, &blocks, tw.storeName, tw.datasetName)
+	if err != nil {
+		return err
+	}
+	err = recordset.Create()
+	if err != nil {
+		return err
+	}
+	return recordset.Write(&blocks)
+}
@@ -2,13 +2,10 @@ package utils // CoordinateList represents a slice of Coordinates, can be used f
 import (
 	"fmt"
 	"io"
-	"math"
 	"math/rand"
 	"strconv"
 	"strings"
 
-	"github.com/HydrologicEngineeringCenter/go-statistics/statistics"
-	"github.com/dewberry/gdal"
 	"github.com/usace-cloud-compute/cc-go-sdk"
 	filestore "github.com/usace-cloud-compute/filesapi"
 )
@@ -32,6 +29,15 @@ func (o *Coordinate) ShiftPoint(offset Coordinate) {
 	o.X += offset.X
 	o.Y += offset.Y
 }
+
+// Bootstrap resamples the coordinates with replacement to a list of the same length.
+func (cl CoordinateList) Bootstrap(rng *rand.Rand) CoordinateList {
+	coordinates := make([]Coordinate, len(cl.Coordinates))
+	for i := range coordinates {
+		coordinates[i] = cl.Coordinates[rng.Intn(len(cl.Coordinates))]
+	}
+	return CoordinateList{Coordinates: coordinates}
+}
 func (c Coordinate) ToString() string {
 	return fmt.Sprintf("%v,%v\r\n", c.X, c.Y)
 }
@@ -169,45 +175,22 @@ func ReadFishNets(iomanager cc.IOManager, storeKey string, filePaths []string, f
 		return CoordinateList{Coordinates: clist}
 	}
 */
+// CreateDensityList samples count locations around the coordinate from a circular normal kernel truncated at the 1-alpha confidence radius,
+// see DensityKernel. The locations are absolute coordinates.
 func CreateDensityList(coordinate Coordinate, alpha float64, radius float64, count int, seed int64) CoordinateList {
-	sn := statistics.NormalDistribution{
-		Mean:              0,
-		StandardDeviation: 1,
-	}
-	divisor := sn.InvCDF(1 - alpha)
-	stdev := radius / divisor
-	rdist := statistics.NormalDistribution{
-		Mean:              0,
-		StandardDeviation: stdev,
-	}
-	tdist := statistics.UniformDistribution{
-		Min: 1,
-		Max: 360,
-	}
-	rng := rand.New(rand.NewSource(seed))
-	clist := make([]Coordinate, count)
-	for i := 0; i < count; i++ {
-
-		theta := tdist.InvCDF(rng.Float64())
-		r := rdist.InvCDF(.5 + .9*rng.Float64())
-		//trig to the rescue.
-		x := r * math.Cos(theta)
-		y := r * math.Sin(theta)
-
-		clist[i] = Coordinate{X: x, Y: y}
-	}
-	return CoordinateList{Coordinates: clist}
+	return IsotropicDensityKernel(radius, alpha).Samples(coordinate, count, seed)
 }
-func ClipDensityList(list CoordinateList, transpositionDomain gdal.Feature) CoordinateList {
+
+// ClipDensityList keeps the locations inside the transposition domain.
+func ClipDensityList(list CoordinateList, transpositionDomain Domain) CoordinateList {
 	output := make([]Coordinate, 0)
-	ref := transpositionDomain.Geometry().SpatialReference()
 	for _, c := range list.Coordinates {
-		//determine if polygon contains the point.
-		location, err := gdal.CreateFromWKT(fmt.Sprintf("Point (%v %v)\n", c.X, c.Y), ref)
+		//determine if the domain contains the point.
+		contained, err := transpositionDomain.ContainsPoint(c)
 		if err != nil {
 			return CoordinateList{Coordinates: output}
 		}
-		if transpositionDomain.Geometry().Contains(location) {
+		if contained {
 			output = append(output, c)
 		}
 	}
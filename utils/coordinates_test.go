@@ -24,8 +24,10 @@ func TestGenerateMasterFishnet(t *testing.T) {
 	path := "/workspaces/hms-mutator/exampledata/trinity/catalog_precip_and_temp.grid"
 	transpositionpath := "/workspaces/hms-mutator/exampledata/trinity/transposition-domain.gpkg"
 	tds := gdal.OpenDataSource(transpositionpath, 0)
-	layer := tds.LayerByIndex(0)
-	polygon := layer.Feature(1)
+	polygon, err := ReadDomain(tds, DomainSelection{})
+	if err != nil {
+		t.Fatal(err)
+	}
 	radius := 50000 //50km
 	alpha := .05    //.05,.95 ci
 	count := 50
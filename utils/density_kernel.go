@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// DensityKernel is a bivariate normal kernel around a storm center truncated to its 1-alpha confidence ellipse. The ellipse has semi
+// axes MajorRadius and MinorRadius, and the major axis is rotated Orientation degrees counter clockwise from the x axis (east).
+// Because the squared mahalanobis distance of a bivariate normal is chi squared with two degrees of freedom, the standard deviation
+// along an axis is its radius divided by sqrt(-2 ln(alpha)).
+type DensityKernel struct {
+	MajorRadius float64
+	MinorRadius float64
+	Orientation float64 //degrees counter clockwise from the x axis.
+	Alpha       float64 //probability of the untruncated normal outside the confidence ellipse.
+}
+
+// IsotropicDensityKernel is a circular kernel with the confidence radius.
+func IsotropicDensityKernel(radius float64, alpha float64) DensityKernel {
+	return DensityKernel{MajorRadius: radius, MinorRadius: radius, Orientation: 0, Alpha: alpha}
+}
+
+// Validate checks the radii are positive and alpha is a probability.
+func (k DensityKernel) Validate() error {
+	if !(k.MajorRadius > 0) || !(k.MinorRadius > 0) || math.IsInf(k.MajorRadius, 0) || math.IsInf(k.MinorRadius, 0) {
+		return errors.New("a density kernel requires positive major and minor radii")
+	}
+	if !(k.Alpha > 0 && k.Alpha < 1) {
+		return errors.New("the alpha of a density kernel must be between 0 and 1")
+	}
+	if math.IsNaN(k.Orientation) || math.IsInf(k.Orientation, 0) {
+		return errors.New("the orientation of a density kernel must be finite")
+	}
+	return nil
+}
+
+// confidenceDistance is the mahalanobis distance of the confidence ellipse.
+func (k DensityKernel) confidenceDistance() float64 {
+	return math.Sqrt(-2 * math.Log(k.Alpha))
+}
+
+// StandardDeviations are the standard deviations of the untruncated normal along the major and minor axes.
+func (k DensityKernel) StandardDeviations() (float64, float64) {
+	c := k.confidenceDistance()
+	return k.MajorRadius / c, k.MinorRadius / c
+}
+
+// Sample draws an offset from the center of the kernel. The squared mahalanobis distance is exponential with a mean of 2, so the
+// truncated distance is sampled by inverting its cdf restricted to the ellipse, and the angle is uniform.
+func (k DensityKernel) Sample(rng *rand.Rand) Coordinate {
+	sdMajor, sdMinor := k.StandardDeviations()
+	r := math.Sqrt(-2 * math.Log(1-rng.Float64()*(1-k.Alpha)))
+	theta := 2 * math.Pi * rng.Float64()
+	u := sdMajor * r * math.Cos(theta)
+	v := sdMinor * r * math.Sin(theta)
+	phi := k.Orientation * math.Pi / 180
+	return Coordinate{X: u*math.Cos(phi) - v*math.Sin(phi), Y: u*math.Sin(phi) + v*math.Cos(phi)}
+}
+
+// Samples draws count locations around the center.
+func (k DensityKernel) Samples(center Coordinate, count int, seed int64) CoordinateList {
+	rng := rand.New(rand.NewSource(seed))
+	clist := make([]Coordinate, count)
+	for i := range clist {
+		offset := k.Sample(rng)
+		clist[i] = Coordinate{X: center.X + offset.X, Y: center.Y + offset.Y}
+	}
+	return CoordinateList{Coordinates: clist}
+}
+
+// Covariance is the covariance (xx, xy, yy) of the truncated kernel. Truncating the exponential squared distance at c^2 scales
+// its mean of 2 by 1-c^2*alpha/(2*(1-alpha)), which scales the variance along each axis by the same factor.
+func (k DensityKernel) Covariance() (float64, float64, float64) {
+	sdMajor, sdMinor := k.StandardDeviations()
+	c2 := -2 * math.Log(k.Alpha)
+	scale := 1 - c2*k.Alpha/(2*(1-k.Alpha))
+	a := sdMajor * sdMajor * scale
+	b := sdMinor * sdMinor * scale
+	phi := k.Orientation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	return a*cos*cos + b*sin*sin, (a - b) * sin * cos, a*sin*sin + b*cos*cos
+}
+
+// Contains is true if the offset from the center of the kernel is inside the confidence ellipse.
+func (k DensityKernel) Contains(offset Coordinate) bool {
+	phi := k.Orientation * math.Pi / 180
+	u := offset.X*math.Cos(phi) + offset.Y*math.Sin(phi)
+	v := -offset.X*math.Sin(phi) + offset.Y*math.Cos(phi)
+	return (u/k.MajorRadius)*(u/k.MajorRadius)+(v/k.MinorRadius)*(v/k.MinorRadius) <= 1+1e-12
+}
+
+// maxDrawsPerLocation bounds the resampling of locations rejected by the clip, so a kernel that barely overlaps the domain gives up.
+const maxDrawsPerLocation = 100
+
+// DensityLocations are the locations sampled around the storm centers of a storm type and the number of draws it took to keep them.
+type DensityLocations struct {
+	Storms    int
+	Draws     int
+	Locations CoordinateList
+}
+
+// SampleDensityLocations draws count locations around each storm center and resamples the locations the clip rejects, so each center keeps
+// count locations unless its kernel rarely overlaps the domain. If there are still fewer than minimum locations, further locations are
+// drawn from the centers in turn until there are. It errors if there are no centers or minimum can not be reached within the draw limit.
+func (k DensityKernel) SampleDensityLocations(centers []Coordinate, count int, minimum int, seed int64, clip func(CoordinateList) CoordinateList) (DensityLocations, error) {
+	result := DensityLocations{Storms: len(centers), Locations: CoordinateList{Coordinates: make([]Coordinate, 0)}}
+	if len(centers) == 0 {
+		return result, errors.New("there are no storm centers to sample around")
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for _, center := range centers {
+		kept := 0
+		for draws := 0; kept < count && draws < maxDrawsPerLocation*count; {
+			batch := k.Samples(center, count-kept, rng.Int63())
+			draws += len(batch.Coordinates)
+			result.Draws += len(batch.Coordinates)
+			inside := clip(batch)
+			kept += len(inside.Coordinates)
+			result.Locations.Coordinates = append(result.Locations.Coordinates, inside.Coordinates...)
+		}
+	}
+	limit := result.Draws + maxDrawsPerLocation*minimum
+	for i := 0; len(result.Locations.Coordinates) < minimum; i++ {
+		if result.Draws >= limit {
+			return result, fmt.Errorf("only %v of the minimum %v locations are inside the domain after %v draws", len(result.Locations.Coordinates), minimum, result.Draws)
+		}
+		inside := clip(k.Samples(centers[i%len(centers)], 1, rng.Int63()))
+		result.Draws++
+		result.Locations.Coordinates = append(result.Locations.Coordinates, inside.Coordinates...)
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDensityKernelMoments(t *testing.T) {
+	kernels := []DensityKernel{
+		IsotropicDensityKernel(50000, .05),
+		{MajorRadius: 80000, MinorRadius: 20000, Orientation: 30, Alpha: .1},
+	}
+	center := Coordinate{X: 1000000, Y: 2000000}
+	count := 200000
+	for _, k := range kernels {
+		if err := k.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		samples := k.Samples(center, count, 1234)
+		var mx, my float64
+		for _, c := range samples.Coordinates {
+			if !k.Contains(Coordinate{X: c.X - center.X, Y: c.Y - center.Y}) {
+				t.Fatalf("sample %v is outside the confidence ellipse of %+v", c, k)
+			}
+			mx += c.X
+			my += c.Y
+		}
+		mx /= float64(count)
+		my /= float64(count)
+		var sxx, sxy, syy float64
+		for _, c := range samples.Coordinates {
+			sxx += (c.X - mx) * (c.X - mx)
+			sxy += (c.X - mx) * (c.Y - my)
+			syy += (c.Y - my) * (c.Y - my)
+		}
+		sxx /= float64(count - 1)
+		sxy /= float64(count - 1)
+		syy /= float64(count - 1)
+		xx, xy, yy := k.Covariance()
+		//the sample mean is the center and the sample covariance is the truncated covariance within sampling error.
+		sd := math.Sqrt(xx + yy)
+		if math.Abs(mx-center.X) > 4*sd/math.Sqrt(float64(count)) || math.Abs(my-center.Y) > 4*sd/math.Sqrt(float64(count)) {
+			t.Errorf("expected a sample mean of %v got %v,%v", center, mx, my)
+		}
+		for _, pair := range [][2]float64{{sxx, xx}, {sxy, xy}, {syy, yy}} {
+			if math.Abs(pair[0]-pair[1]) > .02*(xx+yy) {
+				t.Errorf("expected a sample covariance of %v got %v for %+v", pair[1], pair[0], k)
+			}
+		}
+	}
+	//the major axis variance is the larger eigenvalue.
+	xx, xy, yy := kernels[1].Covariance()
+	sdMajor, _ := kernels[1].StandardDeviations()
+	largest := (xx+yy)/2 + math.Sqrt((xx-yy)*(xx-yy)/4+xy*xy)
+	scale := 1 + 2*math.Log(.1)*.1/(2*.9)
+	if math.Abs(largest-sdMajor*sdMajor*scale) > 1e-6*largest {
+		t.Errorf("expected the major variance %v got %v", sdMajor*sdMajor*scale, largest)
+	}
+}
+
+func TestDensityKernelTruncation(t *testing.T) {
+	k := IsotropicDensityKernel(100, .05)
+	//the untruncated standard deviation puts 95 percent of the bivariate normal inside the radius.
+	sd, _ := k.StandardDeviations()
+	if math.Abs(1-math.Exp(-.5*(100/sd)*(100/sd))-.95) > 1e-12 {
+		t.Errorf("expected 95 percent of the normal inside the radius with a standard deviation of %v", sd)
+	}
+	//the fraction of truncated samples inside half the radius is the truncated chi squared probability.
+	samples := k.Samples(Coordinate{}, 100000, 4321)
+	inside := 0
+	for _, c := range samples.Coordinates {
+		if math.Hypot(c.X, c.Y) <= 50 {
+			inside++
+		}
+	}
+	expected := (1 - math.Exp(-.5*(50/sd)*(50/sd))) / .95
+	if math.Abs(float64(inside)/100000-expected) > .01 {
+		t.Errorf("expected %v of the samples inside half the radius got %v", expected, float64(inside)/100000)
+	}
+	for _, bad := range []DensityKernel{{MajorRadius: 0, MinorRadius: 1, Alpha: .05}, {MajorRadius: 1, MinorRadius: 1, Alpha: 1}} {
+		if bad.Validate() == nil {
+			t.Errorf("expected %+v to be invalid", bad)
+		}
+	}
+}
+
+func TestSampleDensityLocations(t *testing.T) {
+	k := IsotropicDensityKernel(100, .05)
+	//the domain is the half plane east of x=0.
+	east := func(list CoordinateList) CoordinateList {
+		output := make([]Coordinate, 0)
+		for _, c := range list.Coordinates {
+			if c.X >= 0 {
+				output = append(output, c)
+			}
+		}
+		return CoordinateList{Coordinates: output}
+	}
+	//a center on the boundary loses about half its draws which are resampled, a center inside keeps every draw.
+	result, err := k.SampleDensityLocations([]Coordinate{{X: 0, Y: 0}, {X: 1000, Y: 0}}, 50, 0, 1234, east)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Storms != 2 || len(result.Locations.Coordinates) != 100 {
+		t.Errorf("expected 50 locations for each of 2 storms got %v locations for %v storms", len(result.Locations.Coordinates), result.Storms)
+	}
+	if result.Draws <= 100 {
+		t.Errorf("expected the rejected locations to be resampled got %v draws", result.Draws)
+	}
+	for i, c := range result.Locations.Coordinates {
+		if c.X < 0 {
+			t.Fatalf("location %v is outside the domain", c)
+		}
+		center := Coordinate{X: 0}
+		if i >= 50 {
+			center = Coordinate{X: 1000}
+		}
+		if !k.Contains(Coordinate{X: c.X - center.X, Y: c.Y - center.Y}) {
+			t.Fatalf("location %v is not within the kernel of %v", c, center)
+		}
+	}
+	//the minimum tops up a type with few storms.
+	result, err = k.SampleDensityLocations([]Coordinate{{X: 1000, Y: 0}}, 10, 75, 1234, east)
+	if err != nil || len(result.Locations.Coordinates) != 75 {
+		t.Errorf("expected the minimum of 75 locations got %v %v", len(result.Locations.Coordinates), err)
+	}
+	//a kernel entirely outside the domain can not reach the minimum.
+	if _, err = k.SampleDensityLocations([]Coordinate{{X: -1000, Y: 0}}, 10, 5, 1234, east); err == nil {
+		t.Error("expected an error for a kernel outside the domain")
+	}
+	if _, err = k.SampleDensityLocations(nil, 10, 5, 1234, east); err == nil {
+		t.Error("expected an error without storm centers")
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"io"
+	"math/rand"
 	"strconv"
 	"strings"
 
@@ -55,6 +56,23 @@ func (ded DiscreteEmpiricalDistribution) Sample(probability float64) int {
 	}
 	return int(ded.bin_starts[len(ded.bin_starts)-1])
 }
+
+// Bootstrap draws sampleSize values from the distribution and returns the empirical distribution of the sample on the same bins.
+func (ded DiscreteEmpiricalDistribution) Bootstrap(rng *rand.Rand, sampleSize int) DiscreteEmpiricalDistribution {
+	counts := make(map[int]int)
+	for i := 0; i < sampleSize; i++ {
+		counts[ded.Sample(rng.Float64())]++
+	}
+	probs := make([]float64, len(ded.bin_starts))
+	cumulative := 0
+	for i, b := range ded.bin_starts {
+		cumulative += counts[b]
+		probs[i] = float64(cumulative) / float64(sampleSize)
+	}
+	starts := make([]int, len(ded.bin_starts))
+	copy(starts, ded.bin_starts)
+	return NewDescreteEmpiricalDistribution(starts, probs)
+}
 func ReadStormDistributions(iomanager cc.IOManager, storeKey string, filePaths []string, directory string) (StormTypeSeasonalityDistributionMap, error) {
 	StormTypeSeasonalityDistributionMap := make(map[string]DiscreteEmpiricalDistribution)
 	store, err := iomanager.GetStore(storeKey)
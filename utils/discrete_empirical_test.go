@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
 	"testing"
 )
@@ -23,3 +25,24 @@ func TestRead_StormTypeDists(t *testing.T) {
 	fmt.Println(dists.Sample(1.1))
 	fmt.Println(dists)
 }
+func TestBootstrapDistribution(t *testing.T) {
+	dist := NewDescreteEmpiricalDistribution([]int{1, 91, 182, 274}, []float64{.1, .5, .9, 1})
+	rng := rand.New(rand.NewSource(1234))
+	bootstrapped := dist.Bootstrap(rng, 40)
+	previous := 0.0
+	for i, p := range bootstrapped.cumulative_probability {
+		if p < previous {
+			t.Errorf("bootstrapped cumulative probability decreased at bin %v", bootstrapped.bin_starts[i])
+		}
+		previous = p
+	}
+	if previous != 1 {
+		t.Errorf("bootstrapped cumulative probability should end at 1 got %v", previous)
+	}
+	large := dist.Bootstrap(rng, 100000)
+	for i, p := range large.cumulative_probability {
+		if math.Abs(p-dist.cumulative_probability[i]) > .01 {
+			t.Errorf("large bootstrap should approach the original distribution at bin %v, %v vs %v", dist.bin_starts[i], p, dist.cumulative_probability[i])
+		}
+	}
+}
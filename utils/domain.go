@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dewberry/gdal"
+)
+
+// DomainSelection selects the features of a vector datasource that make up a domain.
+type DomainSelection struct {
+	Layer          string  //layer name, the first layer if empty.
+	Filter         string  //OGR SQL where clause (e.g. "name = 'north'"), every feature of the layer if empty.
+	Buffer         float64 //distance the selected features are buffered by in the units of the layer, negative distances buffer inward.
+	StormTypeField string  //field with the storm type of each feature, if provided the features of each storm type are a separate domain.
+}
+
+// DomainSelections are the selections for the transposition region and the watershed boundary.
+type DomainSelections struct {
+	TranspositionRegion DomainSelection
+	WatershedBoundary   DomainSelection
+}
+
+// Domain is the union of the selected polygon features, holes and disjoint parts are preserved.
+type Domain struct {
+	Geometry  gdal.Geometry
+	Reference gdal.SpatialReference
+	Envelope  Envelope
+}
+
+// DomainSet is the union of every selected feature and the union of the features of each storm type.
+type DomainSet struct {
+	Default    Domain
+	StormTypes map[string]Domain //keyed by lower case storm type, empty unless the selection has a storm type field.
+}
+
+// ForStormType returns the domain of the storm type (matched case insensitively), or the default domain if the set is not keyed by storm type.
+func (ds DomainSet) ForStormType(stormType string) (Domain, error) {
+	if len(ds.StormTypes) == 0 {
+		return ds.Default, nil
+	}
+	domain, ok := ds.StormTypes[strings.ToLower(stormType)]
+	if !ok {
+		return domain, fmt.Errorf("there is no transposition domain for storm type %v", stormType)
+	}
+	return domain, nil
+}
+
+// bufferSegments is the number of segments used to approximate a quarter circle when buffering.
+const bufferSegments int = 30
+
+// ReadDomain unions the geometries of the selected features, the features must be polygons or multipolygons.
+func ReadDomain(ds gdal.DataSource, selection DomainSelection) (Domain, error) {
+	selection.StormTypeField = ""
+	set, err := ReadDomainSet(ds, selection)
+	return set.Default, err
+}
+
+// ReadDomainSet unions the geometries of the selected features, and the geometries of each storm type if the selection has a storm type field.
+// the buffer is applied to every domain after the union.
+func ReadDomainSet(ds gdal.DataSource, selection DomainSelection) (DomainSet, error) {
+	set := DomainSet{StormTypes: make(map[string]Domain)}
+	layer, err := selectLayer(ds, selection.Layer)
+	if err != nil {
+		return set, err
+	}
+	if selection.Filter != "" {
+		err = layer.SetAttributeFilter(selection.Filter)
+		if err != nil {
+			return set, fmt.Errorf("could not apply the filter %v to layer %v: %v", selection.Filter, layer.Name(), err)
+		}
+		defer layer.SetAttributeFilter("")
+	}
+	layer.ResetReading()
+	defer layer.ResetReading()
+	unions := make(map[string]gdal.Geometry)
+	defaultKey := "" //storm types are never empty so the union of every feature does not collide with a storm type.
+	for feature := layer.NextFeature(); feature != nil; feature = layer.NextFeature() {
+		stormType := ""
+		if selection.StormTypeField != "" {
+			index := feature.FieldIndex(selection.StormTypeField)
+			if index < 0 {
+				feature.Destroy()
+				return set, fmt.Errorf("layer %v does not have a field named %v", layer.Name(), selection.StormTypeField)
+			}
+			stormType = strings.ToLower(strings.TrimSpace(feature.FieldAsString(index)))
+			if stormType == "" {
+				feature.Destroy()
+				return set, fmt.Errorf("layer %v has a feature without a storm type in %v", layer.Name(), selection.StormTypeField)
+			}
+		}
+		geometry := feature.Geometry().Clone()
+		feature.Destroy()
+		geometry.FlattenTo2D()
+		switch geometry.Type() {
+		case gdal.GT_Polygon, gdal.GT_MultiPolygon:
+		default:
+			geometryType := geometry.Name()
+			geometry.Destroy()
+			return set, fmt.Errorf("layer %v has a %v feature, domains must be polygons or multipolygons", layer.Name(), geometryType)
+		}
+		keys := []string{defaultKey}
+		if stormType != "" {
+			keys = append(keys, stormType)
+		}
+		for _, key := range keys {
+			union, ok := unions[key]
+			if !ok {
+				unions[key] = geometry.Clone()
+				continue
+			}
+			unions[key] = union.Union(geometry)
+			union.Destroy()
+		}
+		geometry.Destroy()
+	}
+	if _, ok := unions[defaultKey]; !ok {
+		return set, fmt.Errorf("no features were selected from layer %v", layer.Name())
+	}
+	for key, union := range unions {
+		domain, err := newDomain(union, layer.SpatialReference(), selection.Buffer)
+		if err != nil {
+			if key != defaultKey {
+				err = fmt.Errorf("storm type %v: %v", key, err)
+			}
+			return set, err
+		}
+		if key == defaultKey {
+			set.Default = domain
+		} else {
+			set.StormTypes[key] = domain
+		}
+	}
+	return set, nil
+}
+
+// newDomain buffers the geometry if the buffer is not zero and computes its envelope.
+func newDomain(geometry gdal.Geometry, ref gdal.SpatialReference, buffer float64) (Domain, error) {
+	if buffer != 0 {
+		buffered := geometry.Buffer(buffer, bufferSegments)
+		geometry.Destroy()
+		geometry = buffered
+	}
+	if geometry.IsEmpty() {
+		geometry.Destroy()
+		return Domain{}, fmt.Errorf("the domain is empty after buffering by %v", buffer)
+	}
+	envelope := geometry.Envelope()
+	return Domain{
+		Geometry:  geometry,
+		Reference: ref,
+		Envelope:  Envelope{MinX: envelope.MinX(), MaxX: envelope.MaxX(), MinY: envelope.MinY(), MaxY: envelope.MaxY()},
+	}, nil
+}
+
+// selectLayer finds the layer by name, or the first layer if the name is empty.
+func selectLayer(ds gdal.DataSource, name string) (gdal.Layer, error) {
+	if ds.LayerCount() == 0 {
+		return gdal.Layer{}, errors.New("the datasource does not have any layers")
+	}
+	if name == "" {
+		return ds.LayerByIndex(0), nil
+	}
+	for i := 0; i < ds.LayerCount(); i++ {
+		layer := ds.LayerByIndex(i)
+		if layer.Name() == name {
+			return layer, nil
+		}
+	}
+	return gdal.Layer{}, fmt.Errorf("the datasource does not have a layer named %v", name)
+}
+
+// ContainsPoint is true if the point is inside the domain, points in holes are outside.
+func (d Domain) ContainsPoint(c Coordinate) (bool, error) {
+	location, err := gdal.CreateFromWKT(fmt.Sprintf("Point (%v %v)\n", c.X, c.Y), d.Reference)
+	if err != nil {
+		return false, err
+	}
+	defer location.Destroy()
+	return d.Geometry.Contains(location), nil
+}
+
+// Centroid is the area weighted centroid of all parts of the domain.
+func (d Domain) Centroid() Coordinate {
+	centroid := d.Geometry.Centroid()
+	defer centroid.Destroy()
+	return Coordinate{X: centroid.X(0), Y: centroid.Y(0)}
+}
+
+// Shifted returns a copy of the domain geometry moved by dx and dy, the caller destroys the copy.
+func (d Domain) Shifted(dx float64, dy float64) gdal.Geometry {
+	shifted := d.Geometry.Clone()
+	ShiftGeometry(shifted, dx, dy)
+	return shifted
+}
+
+// ShiftGeometry moves every point of the geometry by dx and dy in place, including the rings of every part of a multipolygon.
+func ShiftGeometry(geometry gdal.Geometry, dx float64, dy float64) {
+	count := geometry.GeometryCount()
+	if count == 0 {
+		threeDimensional := geometry.CoordinateDimension() == 3
+		for i := 0; i < geometry.PointCount(); i++ {
+			px, py, pz := geometry.Point(i)
+			if threeDimensional {
+				geometry.SetPoint(i, px+dx, py+dy, pz)
+			} else {
+				geometry.SetPoint2D(i, px+dx, py+dy)
+			}
+		}
+		return
+	}
+	for g := 0; g < count; g++ {
+		ShiftGeometry(geometry.Geometry(g), dx, dy) //sub geometries are owned by the parent.
+	}
+}
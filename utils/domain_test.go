@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dewberry/gdal"
+)
+
+// testDomainSource is an in memory layer with a west square (storm type ST1) that has a hole and a separate east square (storm type ST2).
+func testDomainSource(t *testing.T) gdal.DataSource {
+	ds, ok := gdal.OGRDriverByName("Memory").Create("domains", nil)
+	if !ok {
+		t.Fatal("could not create a memory datasource")
+	}
+	ref := gdal.CreateSpatialReference("")
+	ref.FromEPSG(5070)
+	layer := ds.CreateLayer("regions", ref, gdal.GT_Polygon, nil)
+	for _, name := range []string{"name", "storm_type"} {
+		field := gdal.CreateFieldDefinition(name, gdal.FT_String)
+		if err := layer.CreateField(field, true); err != nil {
+			t.Fatal(err)
+		}
+		field.Destroy()
+	}
+	features := map[string]string{
+		"west": "POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (4 4, 6 4, 6 6, 4 6, 4 4))",
+		"east": "POLYGON ((20 0, 30 0, 30 10, 20 10, 20 0))",
+	}
+	stormTypes := map[string]string{"west": "ST1", "east": "ST2"}
+	for name, wkt := range features {
+		geometry, err := gdal.CreateFromWKT(wkt, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		feature := layer.Definition().Create()
+		feature.SetFieldString(0, name)
+		feature.SetFieldString(1, stormTypes[name])
+		feature.SetGeometryDirectly(geometry)
+		if err = layer.Create(feature); err != nil {
+			t.Fatal(err)
+		}
+		feature.Destroy()
+	}
+	return ds
+}
+
+func TestReadDomain(t *testing.T) {
+	ds := testDomainSource(t)
+	defer ds.Destroy()
+	domain, err := ReadDomain(ds, DomainSelection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain.Envelope != (Envelope{MinX: 0, MaxX: 30, MinY: 0, MaxY: 10}) {
+		t.Errorf("expected the envelope of both features got %v", domain.Envelope)
+	}
+	if math.Abs(domain.Geometry.Area()-196) > 1e-9 {
+		t.Errorf("expected the union to exclude the hole with an area of 196 got %v", domain.Geometry.Area())
+	}
+	for c, expected := range map[Coordinate]bool{{X: 2, Y: 2}: true, {X: 5, Y: 5}: false, {X: 15, Y: 5}: false, {X: 25, Y: 5}: true} {
+		contained, err := domain.ContainsPoint(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contained != expected {
+			t.Errorf("expected contains %v to be %v", c, expected)
+		}
+	}
+	east, err := ReadDomain(ds, DomainSelection{Layer: "regions", Filter: "name = 'east'"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if east.Envelope != (Envelope{MinX: 20, MaxX: 30, MinY: 0, MaxY: 10}) || east.Centroid() != (Coordinate{X: 25, Y: 5}) {
+		t.Errorf("expected only the east feature got %v", east.Envelope)
+	}
+	//the filter is cleared so the layer can be read again.
+	again, err := ReadDomain(ds, DomainSelection{})
+	if err != nil || again.Envelope.MinX != 0 {
+		t.Errorf("expected both features after a filtered read got %v %v", again.Envelope, err)
+	}
+	if _, err = ReadDomain(ds, DomainSelection{Layer: "lakes"}); err == nil {
+		t.Error("expected an error for a missing layer")
+	}
+	if _, err = ReadDomain(ds, DomainSelection{Filter: "name = 'north'"}); err == nil {
+		t.Error("expected an error when no features are selected")
+	}
+	//a shifted multipolygon moves every part and hole.
+	shifted := domain.Shifted(100, -50)
+	defer shifted.Destroy()
+	envelope := shifted.Envelope()
+	if envelope.MinX() != 100 || envelope.MaxX() != 130 || envelope.MinY() != -50 || envelope.MaxY() != -40 {
+		t.Errorf("expected the shifted envelope to be 100,130,-50,-40 got %v,%v,%v,%v", envelope.MinX(), envelope.MaxX(), envelope.MinY(), envelope.MaxY())
+	}
+	if math.Abs(shifted.Area()-196) > 1e-9 {
+		t.Errorf("expected the shift to keep the area got %v", shifted.Area())
+	}
+}
+
+// envelopeNear compares envelopes within the precision of buffered geometries.
+func envelopeNear(a Envelope, b Envelope) bool {
+	return math.Abs(a.MinX-b.MinX) < 1e-9 && math.Abs(a.MaxX-b.MaxX) < 1e-9 && math.Abs(a.MinY-b.MinY) < 1e-9 && math.Abs(a.MaxY-b.MaxY) < 1e-9
+}
+
+func TestReadDomainSet(t *testing.T) {
+	ds := testDomainSource(t)
+	defer ds.Destroy()
+	set, err := ReadDomainSet(ds, DomainSelection{StormTypeField: "storm_type", Buffer: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.StormTypes) != 2 || !envelopeNear(set.Default.Envelope, Envelope{MinX: 1, MaxX: 29, MinY: 1, MaxY: 9}) {
+		t.Errorf("expected two storm type domains and a default buffered inward by 1 got %v %v", len(set.StormTypes), set.Default.Envelope)
+	}
+	east, err := set.ForStormType("st2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !envelopeNear(east.Envelope, Envelope{MinX: 21, MaxX: 29, MinY: 1, MaxY: 9}) {
+		t.Errorf("expected the east square buffered inward by 1 got %v", east.Envelope)
+	}
+	west, err := set.ForStormType("ST1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the hole grows by the inward buffer.
+	if contained, _ := west.ContainsPoint(Coordinate{X: 3.5, Y: 5}); contained {
+		t.Error("expected the buffered hole to contain 3.5,5")
+	}
+	if _, err = set.ForStormType("ST3"); err == nil {
+		t.Error("expected an error for a storm type without a domain")
+	}
+	outward, err := ReadDomain(ds, DomainSelection{Filter: "name = 'east'", Buffer: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(outward.Envelope.MinX-18) > 1e-9 || math.Abs(outward.Envelope.MaxY-12) > 1e-9 {
+		t.Errorf("expected the east square buffered outward by 2 got %v", outward.Envelope)
+	}
+	if _, err = ReadDomain(ds, DomainSelection{Buffer: -10}); err == nil {
+		t.Error("expected an error when the buffer removes the domain")
+	}
+	if _, err = ReadDomainSet(ds, DomainSelection{StormTypeField: "season"}); err == nil {
+		t.Error("expected an error for a missing storm type field")
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// FishnetPattern is the arrangement of candidate placements over a transposition region.
+type FishnetPattern string
+
+const (
+	SquareFishnet    FishnetPattern = "square"    //cell centers of a square lattice.
+	HexagonalFishnet FishnetPattern = "hexagonal" //triangular lattice, every point has six neighbors at the spacing.
+	JitteredFishnet  FishnetPattern = "jittered"  //one uniform random point in each cell of the square lattice.
+	SobolFishnet     FishnetPattern = "sobol"     //two dimensional sobol low discrepancy sequence.
+	HaltonFishnet    FishnetPattern = "halton"    //two dimensional halton low discrepancy sequence (bases 2 and 3).
+)
+
+// Envelope is an axis aligned bounding box.
+type Envelope struct {
+	MinX float64
+	MaxX float64
+	MinY float64
+	MaxY float64
+}
+
+// Contains is true if the coordinate is inside or on the edge of the envelope.
+func (e Envelope) Contains(c Coordinate) bool {
+	return c.X >= e.MinX && c.X <= e.MaxX && c.Y >= e.MinY && c.Y <= e.MaxY
+}
+
+// FishnetCandidates generates candidate placements over the envelope with the pattern. Every pattern has about one point per
+// spacing squared of area except hexagonal, where spacing is the distance between neighbors. The seed is only used by the jittered pattern.
+func FishnetCandidates(pattern FishnetPattern, envelope Envelope, spacing float64, seed int64) ([]Coordinate, error) {
+	if !(spacing > 0) || math.IsInf(spacing, 0) {
+		return nil, errors.New("fishnet spacing must be positive")
+	}
+	if !(envelope.MinX < envelope.MaxX) || !(envelope.MinY < envelope.MaxY) {
+		return nil, errors.New("the fishnet envelope is empty")
+	}
+	switch pattern {
+	case SquareFishnet:
+		return squareFishnet(envelope, spacing), nil
+	case HexagonalFishnet:
+		return hexagonalFishnet(envelope, spacing), nil
+	case JitteredFishnet:
+		return jitteredFishnet(envelope, spacing, rand.New(rand.NewSource(seed))), nil
+	case SobolFishnet, HaltonFishnet:
+		width := envelope.MaxX - envelope.MinX
+		height := envelope.MaxY - envelope.MinY
+		count := int(math.Round(width * height / (spacing * spacing)))
+		if count < 1 {
+			count = 1
+		}
+		var unit [][2]float64
+		if pattern == SobolFishnet {
+			unit = SobolSequence(count)
+		} else {
+			unit = HaltonSequence(count)
+		}
+		coordinates := make([]Coordinate, count)
+		for i, u := range unit {
+			coordinates[i] = Coordinate{X: envelope.MinX + u[0]*width, Y: envelope.MinY + u[1]*height}
+		}
+		return coordinates, nil
+	default:
+		return nil, fmt.Errorf("%v is not a fishnet pattern, use %v, %v, %v, %v or %v", pattern, SquareFishnet, HexagonalFishnet, JitteredFishnet, SobolFishnet, HaltonFishnet)
+	}
+}
+
+// squareFishnet is the cell centers of a lattice anchored at the upper left corner of the envelope, only centers inside the envelope are kept.
+func squareFishnet(envelope Envelope, spacing float64) []Coordinate {
+	coordinates := make([]Coordinate, 0)
+	//positions are computed from the row and column so round off does not accumulate.
+	for r := 0; envelope.MaxY-(float64(r)+.5)*spacing >= envelope.MinY; r++ {
+		y := envelope.MaxY - (float64(r)+.5)*spacing
+		for c := 0; envelope.MinX+(float64(c)+.5)*spacing <= envelope.MaxX; c++ {
+			coordinates = append(coordinates, Coordinate{X: envelope.MinX + (float64(c)+.5)*spacing, Y: y})
+		}
+	}
+	return coordinates
+}
+
+// hexagonalFishnet offsets every other row by half the spacing, rows are spacing*sqrt(3)/2 apart.
+func hexagonalFishnet(envelope Envelope, spacing float64) []Coordinate {
+	coordinates := make([]Coordinate, 0)
+	rowSpacing := spacing * math.Sqrt(3) / 2
+	for r := 0; envelope.MaxY-(float64(r)+.5)*rowSpacing >= envelope.MinY; r++ {
+		y := envelope.MaxY - (float64(r)+.5)*rowSpacing
+		offset := spacing / 2
+		if r%2 == 1 {
+			offset = spacing
+		}
+		for c := 0; envelope.MinX+offset+float64(c)*spacing <= envelope.MaxX; c++ {
+			coordinates = append(coordinates, Coordinate{X: envelope.MinX + offset + float64(c)*spacing, Y: y})
+		}
+	}
+	return coordinates
+}
+
+// jitteredFishnet samples one point in each cell of a square lattice covering the envelope, points outside the envelope are dropped.
+func jitteredFishnet(envelope Envelope, spacing float64, rng *rand.Rand) []Coordinate {
+	coordinates := make([]Coordinate, 0)
+	rows := int(math.Ceil((envelope.MaxY - envelope.MinY) / spacing))
+	cols := int(math.Ceil((envelope.MaxX - envelope.MinX) / spacing))
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			//draw both values for every cell so the points do not depend on which cells are dropped.
+			x := envelope.MinX + (float64(c)+rng.Float64())*spacing
+			y := envelope.MaxY - (float64(r)+rng.Float64())*spacing
+			point := Coordinate{X: x, Y: y}
+			if envelope.Contains(point) {
+				coordinates = append(coordinates, point)
+			}
+		}
+	}
+	return coordinates
+}
+
+// radicalInverse reflects the base b digits of i about the radix point.
+func radicalInverse(i int, base int) float64 {
+	inverse := 0.0
+	f := 1.0 / float64(base)
+	for i > 0 {
+		inverse += f * float64(i%base)
+		i /= base
+		f /= float64(base)
+	}
+	return inverse
+}
+
+// HaltonSequence returns the first n points of the two dimensional halton sequence in the unit square, the origin is skipped.
+func HaltonSequence(n int) [][2]float64 {
+	points := make([][2]float64, n)
+	for i := range points {
+		points[i] = [2]float64{radicalInverse(i+1, 2), radicalInverse(i+1, 3)}
+	}
+	return points
+}
+
+// SobolSequence returns the first n points of the two dimensional sobol sequence in the unit square, the origin is skipped.
+// the first dimension is the base 2 van der corput sequence and the second uses the primitive polynomial x+1.
+func SobolSequence(n int) [][2]float64 {
+	const bits = 32
+	var v1, v2 [bits]uint32
+	for k := 0; k < bits; k++ {
+		v1[k] = 1 << (bits - 1 - k)
+		if k == 0 {
+			v2[k] = 1 << (bits - 1)
+		} else {
+			v2[k] = v2[k-1] ^ (v2[k-1] >> 1)
+		}
+	}
+	points := make([][2]float64, n)
+	var x1, x2 uint32
+	for i := 0; i < n; i++ {
+		//gray code construction, flip the direction number of the lowest zero bit of i.
+		c := 0
+		for j := i; j&1 == 1; j >>= 1 {
+			c++
+		}
+		x1 ^= v1[c]
+		x2 ^= v2[c]
+		points[i] = [2]float64{float64(x1) / (1 << bits), float64(x2) / (1 << bits)}
+	}
+	return points
+}
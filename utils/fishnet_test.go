@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFishnetCandidates(t *testing.T) {
+	envelope := Envelope{MinX: 0, MaxX: 10, MinY: 0, MaxY: 8}
+	square, err := FishnetCandidates(SquareFishnet, envelope, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//5 columns and 4 rows of cell centers starting at the upper left cell.
+	if len(square) != 20 || square[0] != (Coordinate{X: 1, Y: 7}) || square[19] != (Coordinate{X: 9, Y: 1}) {
+		t.Errorf("expected 20 cell centers from 1,7 to 9,1 got %v", square)
+	}
+	hex, err := FishnetCandidates(HexagonalFishnet, envelope, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range hex {
+		if !envelope.Contains(c) {
+			t.Errorf("hexagonal point %v is outside the envelope", c)
+		}
+		//every point has a neighbor at the spacing.
+		nearest := math.Inf(1)
+		for j, o := range hex {
+			if i != j {
+				nearest = math.Min(nearest, math.Hypot(c.X-o.X, c.Y-o.Y))
+			}
+		}
+		if math.Abs(nearest-2) > 1e-9 {
+			t.Errorf("expected the nearest neighbor of %v at 2 got %v", c, nearest)
+		}
+	}
+	jittered, err := FishnetCandidates(JitteredFishnet, envelope, 2, 1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jittered) != 20 {
+		t.Fatalf("expected one point in each of the 20 cells got %v", len(jittered))
+	}
+	for i, c := range jittered {
+		cell := square[i]
+		if math.Abs(c.X-cell.X) > 1 || math.Abs(c.Y-cell.Y) > 1 {
+			t.Errorf("jittered point %v is not in the cell centered at %v", c, cell)
+		}
+	}
+	again, _ := FishnetCandidates(JitteredFishnet, envelope, 2, 1234)
+	if again[7] != jittered[7] {
+		t.Error("expected the jittered fishnet to be reproducible for a seed")
+	}
+	for _, pattern := range []FishnetPattern{SobolFishnet, HaltonFishnet} {
+		points, err := FishnetCandidates(pattern, envelope, 2, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 20 {
+			t.Errorf("expected 20 %v points got %v", pattern, len(points))
+		}
+		for _, c := range points {
+			if !envelope.Contains(c) {
+				t.Errorf("%v point %v is outside the envelope", pattern, c)
+			}
+		}
+	}
+	if _, err = FishnetCandidates("triangle", envelope, 2, 0); err == nil {
+		t.Error("expected an error for an unknown pattern")
+	}
+}
+
+func TestLowDiscrepancySequences(t *testing.T) {
+	sobol := SobolSequence(4)
+	expected := [][2]float64{{.5, .5}, {.75, .25}, {.25, .75}, {.375, .375}}
+	for i, p := range expected {
+		if sobol[i] != p {
+			t.Errorf("expected sobol point %v to be %v got %v", i, p, sobol[i])
+		}
+	}
+	halton := HaltonSequence(3)
+	if halton[0] != [2]float64{.5, 1.0 / 3} || halton[1] != [2]float64{.25, 2.0 / 3} || math.Abs(halton[2][1]-1.0/9) > 1e-12 {
+		t.Errorf("unexpected halton points %v", halton)
+	}
+	//every quarter of the unit square holds a quarter of the first 64 sobol points.
+	counts := make(map[[2]bool]int)
+	for _, p := range SobolSequence(64) {
+		counts[[2]bool{p[0] < .5, p[1] < .5}]++
+	}
+	for k, c := range counts {
+		if c != 16 {
+			t.Errorf("expected 16 sobol points in quadrant %v got %v", k, c)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	filestore "github.com/usace-cloud-compute/filesapi"
+)
+
+// PlacementSampling is the density storm placements are drawn from.
+type PlacementSampling string
+
+const (
+	UniformPlacementSampling         PlacementSampling = "uniform"          //every placement is equally likely.
+	TruncatedNormalPlacementSampling PlacementSampling = "truncated_normal" //placements near a center (typically the watershed centroid) are more likely.
+	DepthPlacementSampling           PlacementSampling = "depth"            //placements are likely in proportion to the basin average depth of the storm.
+)
+
+// PlacementDensity describes the biased density used to importance sample storm placements.
+type PlacementDensity struct {
+	Method            PlacementSampling
+	Center            Coordinate
+	StandardDeviation float64
+	UniformFraction   float64 //fraction of the density that stays uniform so every placement can still be sampled.
+}
+
+// Validate checks the density parameters are usable for its method.
+func (pd PlacementDensity) Validate() error {
+	switch pd.Method {
+	case UniformPlacementSampling:
+		return nil
+	case TruncatedNormalPlacementSampling:
+		if !(pd.StandardDeviation > 0) || math.IsInf(pd.StandardDeviation, 0) {
+			return errors.New("truncated normal placement sampling requires a positive standard deviation")
+		}
+	case DepthPlacementSampling:
+	default:
+		return fmt.Errorf("%v is not a placement sampling method, use %v, %v or %v", pd.Method, UniformPlacementSampling, TruncatedNormalPlacementSampling, DepthPlacementSampling)
+	}
+	if math.IsNaN(pd.UniformFraction) || pd.UniformFraction < 0 || pd.UniformFraction > 1 {
+		return errors.New("the uniform fraction of a placement density must be between 0 and 1")
+	}
+	return nil
+}
+
+// TruncatedNormal is a normal distribution truncated to [Min, Max], it satisfies statistics.ContinuousDistribution.
+type TruncatedNormal struct {
+	Mean              float64
+	StandardDeviation float64
+	Min               float64
+	Max               float64
+}
+
+func standardNormalCDF(z float64) float64 {
+	return .5 * math.Erfc(-z/math.Sqrt2)
+}
+func standardNormalPDF(z float64) float64 {
+	return math.Exp(-.5*z*z) / math.Sqrt(2*math.Pi)
+}
+
+// mass is the standard normal probability of the lower and upper bounds.
+func (tn TruncatedNormal) mass() (float64, float64) {
+	return standardNormalCDF((tn.Min - tn.Mean) / tn.StandardDeviation), standardNormalCDF((tn.Max - tn.Mean) / tn.StandardDeviation)
+}
+
+// Validate checks the bounds are ordered and hold enough of the normal distribution to sample from.
+func (tn TruncatedNormal) Validate() error {
+	if !(tn.StandardDeviation > 0) {
+		return errors.New("a truncated normal requires a positive standard deviation")
+	}
+	if !(tn.Min < tn.Max) {
+		return errors.New("a truncated normal requires a minimum less than the maximum")
+	}
+	lower, upper := tn.mass()
+	if upper-lower <= 0 {
+		return fmt.Errorf("the normal distribution with mean %v and standard deviation %v has no probability between %v and %v", tn.Mean, tn.StandardDeviation, tn.Min, tn.Max)
+	}
+	return nil
+}
+func (tn TruncatedNormal) InvCDF(probability float64) float64 {
+	lower, upper := tn.mass()
+	p := lower + probability*(upper-lower)
+	x := tn.Mean - tn.StandardDeviation*math.Sqrt2*math.Erfcinv(2*p)
+	return math.Min(math.Max(x, tn.Min), tn.Max)
+}
+func (tn TruncatedNormal) CDF(value float64) float64 {
+	if value <= tn.Min {
+		return 0
+	}
+	if value >= tn.Max {
+		return 1
+	}
+	lower, upper := tn.mass()
+	return (standardNormalCDF((value-tn.Mean)/tn.StandardDeviation) - lower) / (upper - lower)
+}
+func (tn TruncatedNormal) PDF(value float64) float64 {
+	if value < tn.Min || value > tn.Max {
+		return 0
+	}
+	lower, upper := tn.mass()
+	return standardNormalPDF((value-tn.Mean)/tn.StandardDeviation) / (tn.StandardDeviation * (upper - lower))
+}
+func (tn TruncatedNormal) CentralTendency() float64 {
+	lower, upper := tn.mass()
+	alpha := (tn.Min - tn.Mean) / tn.StandardDeviation
+	beta := (tn.Max - tn.Mean) / tn.StandardDeviation
+	return tn.Mean + tn.StandardDeviation*(standardNormalPDF(alpha)-standardNormalPDF(beta))/(upper-lower)
+}
+
+// ImportanceDistribution samples an index from a biased density and reports the likelihood ratio of the index to uniform sampling,
+// the mean of a quantity times its likelihood ratio over the samples is an unbiased estimate of its mean under uniform sampling.
+type ImportanceDistribution struct {
+	distribution WeightedIndexDistribution
+	ratios       []float64
+}
+
+// NewImportanceDistribution mixes the normalized scores with uniformFraction of uniform probability (defensive importance sampling),
+// scores must be finite and non-negative and at least one must be positive unless the distribution is fully uniform.
+func NewImportanceDistribution(scores []float64, uniformFraction float64) (ImportanceDistribution, error) {
+	if len(scores) == 0 {
+		return ImportanceDistribution{}, errors.New("an importance distribution requires at least one score")
+	}
+	if math.IsNaN(uniformFraction) || uniformFraction < 0 || uniformFraction > 1 {
+		return ImportanceDistribution{}, errors.New("the uniform fraction must be between 0 and 1")
+	}
+	total := 0.0
+	for i, s := range scores {
+		if math.IsNaN(s) || math.IsInf(s, 0) || s < 0 {
+			return ImportanceDistribution{}, fmt.Errorf("score %v is %v, scores must be finite and non-negative", i, s)
+		}
+		total += s
+	}
+	if total <= 0 && uniformFraction < 1 {
+		return ImportanceDistribution{}, errors.New("the scores must sum to a positive value")
+	}
+	n := float64(len(scores))
+	probabilities := make([]float64, len(scores))
+	ratios := make([]float64, len(scores))
+	for i, s := range scores {
+		probabilities[i] = uniformFraction / n
+		if total > 0 {
+			probabilities[i] += (1 - uniformFraction) * s / total
+		}
+		ratios[i] = math.Inf(1) //never sampled.
+		if probabilities[i] > 0 {
+			ratios[i] = 1 / (n * probabilities[i])
+		}
+	}
+	return ImportanceDistribution{distribution: NewWeightedIndexDistribution(probabilities), ratios: ratios}, nil
+}
+
+// Sample returns the index associated with the probability and its likelihood ratio.
+func (id ImportanceDistribution) Sample(probability float64) (int, float64) {
+	index := id.distribution.Sample(probability)
+	return index, id.ratios[index]
+}
+
+// Probability returns the sampling probability of an index.
+func (id ImportanceDistribution) Probability(index int) float64 {
+	return id.distribution.Weight(index)
+}
+
+// LikelihoodRatio returns the uniform probability of an index divided by its sampling probability.
+func (id ImportanceDistribution) LikelihoodRatio(index int) float64 {
+	return id.ratios[index]
+}
+
+// TruncatedNormalScores is the bivariate normal density around center for each coordinate, normalizing the scores over the coordinates
+// truncates the normal to the coordinates.
+func TruncatedNormalScores(coordinates []Coordinate, center Coordinate, standardDeviation float64) []float64 {
+	scores := make([]float64, len(coordinates))
+	for i, c := range coordinates {
+		dx := (c.X - center.X) / standardDeviation
+		dy := (c.Y - center.Y) / standardDeviation
+		scores[i] = math.Exp(-.5 * (dx*dx + dy*dy))
+	}
+	return scores
+}
+
+// DepthScores is the basin average depth at each coordinate, coordinates without a positive depth score zero.
+func DepthScores(coordinates []Coordinate, depths map[Coordinate]float64) []float64 {
+	scores := make([]float64, len(coordinates))
+	for i, c := range coordinates {
+		d, ok := depths[c]
+		if ok && d > 0 && !math.IsInf(d, 0) {
+			scores[i] = d
+		}
+	}
+	return scores
+}
+
+// BasinAverageDepthsFromBytes reads a csv with a header and x,y,basin_average_depth rows (see the basin_average_depths action),
+// rows with an empty depth are skipped.
+func BasinAverageDepthsFromBytes(data []byte) (map[Coordinate]float64, error) {
+	depths := make(map[Coordinate]float64)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if i == 0 || len(strings.TrimSpace(line)) == 0 {
+			continue //skip header and empty lines
+		}
+		vals := strings.Split(line, ",")
+		if len(vals) < 3 {
+			return depths, fmt.Errorf("basin average depth line %v does not have an x, y and depth", i+1)
+		}
+		if strings.TrimSpace(vals[2]) == "" {
+			continue
+		}
+		parsed := make([]float64, 3)
+		for j := range parsed {
+			v, err := strconv.ParseFloat(strings.TrimSpace(vals[j]), 64)
+			if err != nil {
+				return depths, fmt.Errorf("could not parse basin average depth line %v: %v", i+1, err)
+			}
+			parsed[j] = v
+		}
+		depths[Coordinate{X: parsed[0], Y: parsed[1]}] = parsed[2]
+	}
+	return depths, nil
+}
+
+// ReadBasinAverageDepths reads the basin average depth csv files keyed by file name without extension.
+func ReadBasinAverageDepths(iomanager cc.IOManager, storeKey string, filePaths []string, directory string) (map[string]map[Coordinate]float64, error) {
+	depthMap := make(map[string]map[Coordinate]float64)
+	store, err := iomanager.GetStore(storeKey)
+	if err != nil {
+		return depthMap, err
+	}
+	session, ok := store.Session.(*cc.FileDataStore[filestore.S3FS])
+	if !ok {
+		return depthMap, fmt.Errorf("%v was not an s3datastore type", storeKey)
+	}
+	root := store.Parameters.GetStringOrFail("root")
+	for _, path := range filePaths {
+		path = fmt.Sprintf("%v%v", directory, path)
+		pathpart := strings.Replace(path, fmt.Sprintf("%v/", root), "", -1)
+		reader, err := session.Get(pathpart, "")
+		if err != nil {
+			return depthMap, err
+		}
+		bytes, err := io.ReadAll(reader)
+		if err != nil {
+			return depthMap, err
+		}
+		depths, err := BasinAverageDepthsFromBytes(bytes)
+		if err != nil {
+			return depthMap, fmt.Errorf("%v: %v", path, err)
+		}
+		parts := strings.Split(path, "/")
+		lastpart := parts[len(parts)-1]
+		name := strings.TrimSuffix(lastpart, ".csv")
+		depthMap[name] = depths
+	}
+	return depthMap, nil
+}
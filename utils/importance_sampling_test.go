@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestImportanceDistribution(t *testing.T) {
+	coordinates := []Coordinate{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 20, Y: 0}, {X: 30, Y: 0}}
+	scores := TruncatedNormalScores(coordinates, Coordinate{X: 0, Y: 0}, 10)
+	if scores[0] != 1 || math.Abs(scores[1]-math.Exp(-.5)) > 1e-12 {
+		t.Fatalf("expected normal kernel scores got %v", scores)
+	}
+	dist, err := NewImportanceDistribution(scores, .1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the expectation of the likelihood ratio times any quantity under the biased density is its uniform mean.
+	total := 0.0
+	for i := range coordinates {
+		total += dist.Probability(i)
+		if math.Abs(dist.Probability(i)*dist.LikelihoodRatio(i)-.25) > 1e-12 {
+			t.Errorf("expected probability times likelihood ratio of .25 for %v got %v", i, dist.Probability(i)*dist.LikelihoodRatio(i))
+		}
+	}
+	if math.Abs(total-1) > 1e-12 {
+		t.Errorf("expected probabilities to sum to one got %v", total)
+	}
+	rng := rand.New(rand.NewSource(1234))
+	estimate := 0.0
+	samples := 100000
+	for i := 0; i < samples; i++ {
+		index, ratio := dist.Sample(rng.Float64())
+		estimate += coordinates[index].X * ratio
+	}
+	estimate /= float64(samples)
+	if math.Abs(estimate-15) > .5 {
+		t.Errorf("expected a weighted mean x of about 15 got %v", estimate)
+	}
+	//depth scores ignore missing and non positive depths, the uniform fraction keeps them possible.
+	depths := map[Coordinate]float64{{X: 0, Y: 0}: 2, {X: 10, Y: 0}: -1, {X: 20, Y: 0}: 6}
+	dscores := DepthScores(coordinates, depths)
+	if dscores[0] != 2 || dscores[1] != 0 || dscores[2] != 6 || dscores[3] != 0 {
+		t.Errorf("expected depth scores [2 0 6 0] got %v", dscores)
+	}
+	ddist, err := NewImportanceDistribution(dscores, .2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(ddist.Probability(3)-.05) > 1e-12 || math.Abs(ddist.LikelihoodRatio(2)-.25/(.05+.6)) > 1e-12 {
+		t.Errorf("unexpected depth probabilities %v %v", ddist.Probability(3), ddist.LikelihoodRatio(2))
+	}
+	if _, err = NewImportanceDistribution([]float64{0, 0}, 0); err == nil {
+		t.Error("expected an error for scores without a positive value")
+	}
+	if _, err = NewImportanceDistribution([]float64{1, math.NaN()}, .1); err == nil {
+		t.Error("expected an error for a NaN score")
+	}
+}
+
+func TestTruncatedNormal(t *testing.T) {
+	tn := TruncatedNormal{Mean: 0, StandardDeviation: 1, Min: -1, Max: 2}
+	if err := tn.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []float64{0, .1, .5, .9, 1} {
+		x := tn.InvCDF(p)
+		if x < tn.Min || x > tn.Max {
+			t.Errorf("InvCDF(%v)=%v is outside the bounds", p, x)
+		}
+		if math.Abs(tn.CDF(x)-p) > 1e-9 {
+			t.Errorf("expected CDF(InvCDF(%v)) to be %v got %v", p, p, tn.CDF(x))
+		}
+	}
+	//integrate the pdf over the bounds.
+	integral := 0.0
+	steps := 30000
+	dx := (tn.Max - tn.Min) / float64(steps)
+	for i := 0; i < steps; i++ {
+		integral += tn.PDF(tn.Min+(float64(i)+.5)*dx) * dx
+	}
+	if math.Abs(integral-1) > 1e-6 {
+		t.Errorf("expected the pdf to integrate to one got %v", integral)
+	}
+	if tn.PDF(3) != 0 {
+		t.Error("expected zero density outside the bounds")
+	}
+	if (TruncatedNormal{Mean: 100, StandardDeviation: 1, Min: -1, Max: 1}).Validate() == nil {
+		t.Error("expected an error for bounds without probability")
+	}
+}
+
+func TestBasinAverageDepthsFromBytes(t *testing.T) {
+	data := []byte("x,y,basin_average_depth\n100,200,1.5\n110,200,\n120.5,200,0\n")
+	depths, err := BasinAverageDepthsFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(depths) != 2 || depths[Coordinate{X: 100, Y: 200}] != 1.5 {
+		t.Errorf("expected two depths with 1.5 at 100,200 got %v", depths)
+	}
+	if _, ok := depths[Coordinate{X: 110, Y: 200}]; ok {
+		t.Error("expected the missing depth to be skipped")
+	}
+	if _, err = BasinAverageDepthsFromBytes([]byte("x,y,basin_average_depth\n100,200\n")); err == nil {
+		t.Error("expected an error for a row without a depth column")
+	}
+}
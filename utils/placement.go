@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DepthRaster is a total depth grid held in memory, values are row major.
+type DepthRaster struct {
+	GeoTransform [6]float64
+	XSize        int
+	YSize        int
+	NoData       float64
+	HasNoData    bool
+	Projection   string //well known text, empty if unknown.
+	Values       []float64
+}
+
+// LocationGridNoData is the value of cells without a location in a LocationGrid.
+const LocationGridNoData float64 = -9999
+
+// TotalDepth sums the time steps of a precipitation field, a cell is nodata if it is nodata in any time step.
+func (pf PrecipitationField) TotalDepth() (DepthRaster, error) {
+	dr := DepthRaster{GeoTransform: pf.GeoTransform, XSize: pf.XSize, YSize: pf.YSize, NoData: pf.NoData, HasNoData: pf.HasNoData, Projection: pf.Projection}
+	if err := pf.validate(); err != nil {
+		return dr, err
+	}
+	dr.Values = make([]float64, pf.XSize*pf.YSize)
+	for idx := range dr.Values {
+		for _, s := range pf.Steps {
+			if math.IsNaN(s[idx]) || (pf.HasNoData && s[idx] == pf.NoData) {
+				dr.Values[idx] = math.NaN()
+				break
+			}
+			dr.Values[idx] += s[idx]
+		}
+	}
+	return dr, nil
+}
+
+// SameGrid returns an error unless the raster has the geotransform and size of the other raster, a watershed footprint computed
+// on one raster can only be placed on rasters with the same grid.
+func (dr DepthRaster) SameGrid(other DepthRaster) error {
+	if dr.GeoTransform != other.GeoTransform || dr.XSize != other.XSize || dr.YSize != other.YSize {
+		return fmt.Errorf("a raster with geotransform %v and size %vx%v does not match the geotransform %v and size %vx%v of the first storm raster", dr.GeoTransform, dr.XSize, dr.YSize, other.GeoTransform, other.XSize, other.YSize)
+	}
+	return nil
+}
+
+// WatershedFootprint is the set of cell centers inside a watershed, it is computed once and shifted to every placement.
+type WatershedFootprint struct {
+	Cells    []Coordinate
+	CellArea float64
+}
+
+// NewWatershedFootprint finds the cell centers of the raster grid that are inside the watershed. The cells are aligned to the raster
+// grid but are not limited to the raster extent so the footprint can be shifted anywhere.
+func NewWatershedFootprint(watershed Domain, grid DepthRaster) (WatershedFootprint, error) {
+	footprint := WatershedFootprint{Cells: make([]Coordinate, 0)}
+	envelope := watershed.Envelope
+	gt := grid.GeoTransform
+	cellWidth := math.Abs(gt[1])
+	cellHeight := math.Abs(gt[5])
+	footprint.CellArea = cellWidth * cellHeight
+	minCol := int(math.Floor((envelope.MinX - gt[0]) / cellWidth))
+	maxCol := int(math.Ceil((envelope.MaxX - gt[0]) / cellWidth))
+	minRow := int(math.Floor((gt[3] - envelope.MaxY) / cellHeight))
+	maxRow := int(math.Ceil((gt[3] - envelope.MinY) / cellHeight))
+	for row := minRow; row < maxRow; row++ {
+		y := gt[3] - (float64(row)+.5)*cellHeight
+		for col := minCol; col < maxCol; col++ {
+			x := gt[0] + (float64(col)+.5)*cellWidth
+			contained, err := watershed.ContainsPoint(Coordinate{X: x, Y: y})
+			if err != nil {
+				return footprint, err
+			}
+			if contained {
+				footprint.Cells = append(footprint.Cells, Coordinate{X: x, Y: y})
+			}
+		}
+	}
+	if len(footprint.Cells) == 0 {
+		return footprint, errors.New("the watershed boundary does not contain any raster cell centers")
+	}
+	return footprint, nil
+}
+
+// PlacementStatistics are the depth statistics over the watershed for a storm placed at a location.
+// A placement is valid if every watershed cell has data and at least one cell exceeds the acceptance threshold.
+type PlacementStatistics struct {
+	Valid   bool
+	Missing int //watershed cells outside the storm raster or nodata.
+	Mean    float64
+	Max     float64
+	Volume  float64 //total depth times cell area.
+}
+
+// pixel returns the index of the cell containing a coordinate or -1 if it is outside the raster.
+func (dr DepthRaster) pixel(x float64, y float64) int {
+	gt := dr.GeoTransform
+	//the raster is expected to be north up, the rotation terms are ignored.
+	col := int(math.Floor((x - gt[0]) / gt[1]))
+	row := int(math.Floor((y - gt[3]) / gt[5]))
+	if col < 0 || col >= dr.XSize || row < 0 || row >= dr.YSize {
+		return -1
+	}
+	return row*dr.XSize + col
+}
+
+// Place evaluates the storm centered at stormCenter transposed to candidate, each watershed cell reads the storm raster at the cell
+// shifted by the inverse of the transposition so no raster io is needed.
+func (dr DepthRaster) Place(footprint WatershedFootprint, stormCenter Coordinate, candidate Coordinate, acceptanceThreshold float64) PlacementStatistics {
+	stats := PlacementStatistics{}
+	dx := stormCenter.X - candidate.X
+	dy := stormCenter.Y - candidate.Y
+	exceeds := false
+	count := 0
+	total := 0.0
+	for _, c := range footprint.Cells {
+		idx := dr.pixel(c.X+dx, c.Y+dy)
+		if idx < 0 {
+			stats.Missing++
+			continue
+		}
+		v := dr.Values[idx]
+		if math.IsNaN(v) || (dr.HasNoData && v == dr.NoData) {
+			stats.Missing++
+			continue
+		}
+		if v > acceptanceThreshold {
+			exceeds = true
+		}
+		if count == 0 || v > stats.Max {
+			stats.Max = v
+		}
+		total += v
+		count++
+	}
+	if count > 0 {
+		stats.Mean = total / float64(count)
+		stats.Volume = total * footprint.CellArea
+	}
+	stats.Valid = exceeds && stats.Missing == 0
+	return stats
+}
+
+// PlaceAll evaluates every candidate location for the storm.
+func (dr DepthRaster) PlaceAll(footprint WatershedFootprint, stormCenter Coordinate, candidates []Coordinate, acceptanceThreshold float64) []PlacementStatistics {
+	stats := make([]PlacementStatistics, len(candidates))
+	for i, c := range candidates {
+		stats[i] = dr.Place(footprint, stormCenter, c, acceptanceThreshold)
+	}
+	return stats
+}
+
+// LocationGrid maps a value for each location on a uniform fishnet (see generateUniformPointList) to a raster with one cell per location,
+// cells without a location are LocationGridNoData.
+func LocationGrid(locations []Coordinate, values []float64, spacing float64, projection string) (DepthRaster, error) {
+	if len(locations) == 0 || len(locations) != len(values) {
+		return DepthRaster{}, errors.New("a location grid requires one value for each location")
+	}
+	if spacing <= 0 {
+		return DepthRaster{}, errors.New("a location grid requires a positive spacing")
+	}
+	minX, maxX := locations[0].X, locations[0].X
+	minY, maxY := locations[0].Y, locations[0].Y
+	for _, l := range locations {
+		minX, maxX = math.Min(minX, l.X), math.Max(maxX, l.X)
+		minY, maxY = math.Min(minY, l.Y), math.Max(maxY, l.Y)
+	}
+	dr := DepthRaster{
+		GeoTransform: [6]float64{minX - spacing/2, spacing, 0, maxY + spacing/2, 0, -spacing},
+		XSize:        int(math.Round((maxX-minX)/spacing)) + 1,
+		YSize:        int(math.Round((maxY-minY)/spacing)) + 1,
+		NoData:       LocationGridNoData,
+		HasNoData:    true,
+		Projection:   projection,
+	}
+	dr.Values = make([]float64, dr.XSize*dr.YSize)
+	for i := range dr.Values {
+		dr.Values[i] = LocationGridNoData
+	}
+	for i, l := range locations {
+		col := int(math.Round((l.X - minX) / spacing))
+		row := int(math.Round((maxY - l.Y) / spacing))
+		v := values[i]
+		if math.IsNaN(v) {
+			v = LocationGridNoData
+		}
+		dr.Values[row*dr.XSize+col] = v
+	}
+	return dr, nil
+}
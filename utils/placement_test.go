@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPlaceStorm(t *testing.T) {
+	//a 4x3 storm with 10 unit cells, the storm center is the center of cell (1,1).
+	pf := testPrecipitationField()
+	pf.XSize, pf.YSize = 4, 3
+	pf.GeoTransform = [6]float64{0, 10, 0, 30, 0, -10}
+	pf.Steps = [][]float64{
+		{0, 1, 0, 0, 1, 2, 1, 0, 0, 1, 0, -9999},
+		{0, 1, 0, 0, 1, 2, 1, 0, 0, 1, 0, 0},
+	}
+	depth, err := pf.TotalDepth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth.Values[5] != 4 || !math.IsNaN(depth.Values[11]) {
+		t.Fatalf("expected total depth 4 at the center and nodata in the corner got %v", depth.Values)
+	}
+	//a two cell watershed centered at 105,205
+	footprint := WatershedFootprint{Cells: []Coordinate{{X: 105, Y: 205}, {X: 115, Y: 205}}, CellArea: 100}
+	stormCenter := Coordinate{X: 15, Y: 15}
+	stats := depth.Place(footprint, stormCenter, Coordinate{X: 105, Y: 205}, .5)
+	if !stats.Valid || stats.Max != 4 || stats.Mean != 3 || stats.Volume != 600 {
+		t.Errorf("expected a valid placement with max 4, mean 3 and volume 600 got %+v", stats)
+	}
+	all := depth.PlaceAll(footprint, stormCenter, []Coordinate{{X: 95, Y: 205}, {X: 85, Y: 205}, {X: 95, Y: 195}, {X: 95, Y: 215}}, .5)
+	if !all[0].Valid || all[0].Mean != 1 || all[0].Max != 2 {
+		t.Errorf("expected the placement one cell west to be valid got %+v", all[0])
+	}
+	if all[1].Valid || all[1].Missing != 1 {
+		t.Errorf("expected the placement off the raster to be invalid got %+v", all[1])
+	}
+	if all[2].Valid || all[2].Missing != 0 || all[2].Max != 0 {
+		t.Errorf("expected the placement without depth to be invalid got %+v", all[2])
+	}
+	if all[3].Valid || all[3].Missing != 1 {
+		t.Errorf("expected the placement over nodata to be invalid got %+v", all[3])
+	}
+	shifted := depth
+	shifted.GeoTransform[0] = 5
+	if depth.SameGrid(depth) != nil || depth.SameGrid(shifted) == nil {
+		t.Error("expected only rasters with the same geotransform and size to share a grid")
+	}
+}
+func TestLocationGrid(t *testing.T) {
+	locations := []Coordinate{{X: 15, Y: 45}, {X: 25, Y: 45}, {X: 45, Y: 45}, {X: 25, Y: 35}}
+	grid, err := LocationGrid(locations, []float64{1, 2, 3, math.NaN()}, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grid.XSize != 4 || grid.YSize != 2 || grid.GeoTransform[0] != 10 || grid.GeoTransform[3] != 50 {
+		t.Fatalf("expected a 4x2 grid at 10,50 got %vx%v at %v,%v", grid.XSize, grid.YSize, grid.GeoTransform[0], grid.GeoTransform[3])
+	}
+	expected := []float64{1, 2, LocationGridNoData, 3, LocationGridNoData, LocationGridNoData, LocationGridNoData, LocationGridNoData}
+	for i, v := range expected {
+		if grid.Values[i] != v {
+			t.Errorf("expected %v at cell %v got %v", v, i, grid.Values[i])
+		}
+	}
+	if grid.pixel(25, 45) != 1 {
+		t.Errorf("expected the location grid cells to be centered on the locations")
+	}
+	_, err = LocationGrid(locations, []float64{1}, 10, "")
+	if err == nil {
+		t.Error("expected an error for a value count that does not match the locations")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"slices"
 
 	"github.com/usace-cloud-compute/cc-go-sdk"
@@ -156,3 +157,117 @@ func (reader *TileDbSeedReader) Read() ([]SeedSet, error) {
 	}
 	return seeds, nil
 }
+
+type TileDbSeedWriter struct {
+	store       *cc.DataStore
+	datasetName string
+	seedColumns []string
+}
+
+func NewTileDbSeedWriter(tiledbStore *cc.DataStore, datasetName string, seedColumns []string) *TileDbSeedWriter {
+	return &TileDbSeedWriter{
+		store:       tiledbStore,
+		datasetName: datasetName,
+		seedColumns: seedColumns,
+	}
+}
+
+// Write stores the seeds in the layout the TileDbSeedReader expects, seeds has one slice of event seed sets per seed column.
+func (writer *TileDbSeedWriter) Write(seeds [][]SeedSet) error {
+	if len(seeds) != len(writer.seedColumns) || len(seeds) == 0 {
+		return fmt.Errorf("expected seeds for %v seed columns but found %v", len(writer.seedColumns), len(seeds))
+	}
+	rows := len(seeds[0])
+	if rows == 0 {
+		return fmt.Errorf("there are no seeds to write")
+	}
+	cols := len(seeds)
+	realizationSeeds := make([]int64, rows*cols)
+	blockSeeds := make([]int64, rows*cols)
+	eventSeeds := make([]int64, rows*cols)
+	for c, column := range seeds {
+		if len(column) != rows {
+			return fmt.Errorf("seed column %v has %v seeds but %v were expected", writer.seedColumns[c], len(column), rows)
+		}
+		for r, s := range column {
+			//row major, rows are events and columns are plugins
+			realizationSeeds[r*cols+c] = s.RealizationSeed
+			blockSeeds[r*cols+c] = s.BlockSeed
+			eventSeeds[r*cols+c] = s.EventSeed
+		}
+	}
+	tdbms, ok := writer.store.Session.(cc.MetadataStore)
+	if !ok {
+		return fmt.Errorf("the store named %v does not implement metadata store", writer.store.Name)
+	}
+	tdbmdas, ok := writer.store.Session.(cc.MultiDimensionalArrayStore)
+	if !ok {
+		return fmt.Errorf("the store named %v does not implement multidimensional array store", writer.store.Name)
+	}
+	err := tdbmdas.CreateArray(cc.CreateArrayInput{
+		Attributes: []cc.ArrayAttribute{
+			{Name: "realization_seed", DataType: cc.ATTR_INT64},
+			{Name: "block_seed", DataType: cc.ATTR_INT64},
+			{Name: "event_seed", DataType: cc.ATTR_INT64},
+		},
+		Dimensions: []cc.ArrayDimension{
+			{Name: "events", DimensionType: cc.DIMENSION_INT, Domain: []int64{1, int64(rows)}, TileExtent: min(int64(rows), 256)},
+			{Name: "plugins", DimensionType: cc.DIMENSION_INT, Domain: []int64{1, int64(cols)}, TileExtent: int64(cols)},
+		},
+		ArrayPath:  writer.datasetName,
+		ArrayType:  cc.ARRAY_DENSE,
+		CellLayout: cc.ROWMAJOR,
+		TileLayout: cc.ROWMAJOR,
+	})
+	if err != nil {
+		return err
+	}
+	err = tdbmdas.PutArray(cc.PutArrayInput{
+		Buffers: []cc.PutArrayBuffer{
+			{AttrName: "realization_seed", Buffer: realizationSeeds},
+			{AttrName: "block_seed", Buffer: blockSeeds},
+			{AttrName: "event_seed", Buffer: eventSeeds},
+		},
+		BufferRange: []int64{1, int64(rows), 1, int64(cols)},
+		DataPath:    writer.datasetName,
+		ArrayType:   cc.ARRAY_DENSE,
+		PutLayout:   cc.ROWMAJOR,
+	})
+	if err != nil {
+		return err
+	}
+	return tdbms.PutMetadata("seed_columns", writer.seedColumns)
+}
+
+// GenerateRealizationSeeds creates seed sets for the events in the blocks from a column seed.
+// every event in a realization shares the realization seed, and the block seed of the realization used to generate the blocks.
+func GenerateRealizationSeeds(columnSeed int64, realizationBlockSeeds []int64, blocks []Block) []SeedSet {
+	rng := rand.New(rand.NewSource(columnSeed))
+	realizationSeeds := make([]int64, len(realizationBlockSeeds))
+	for i := range realizationSeeds {
+		realizationSeeds[i] = rng.Int63()
+	}
+	seeds := make([]SeedSet, 0)
+	for _, b := range blocks {
+		for en := b.BlockEventStart; en <= b.BlockEventEnd; en++ {
+			seeds = append(seeds, SeedSet{
+				EventSeed:       rng.Int63(),
+				BlockSeed:       realizationBlockSeeds[b.RealizationIndex-1],
+				RealizationSeed: realizationSeeds[b.RealizationIndex-1],
+			})
+		}
+	}
+	return seeds
+}
+
+// RealizationBlockSeeds finds the block seed of each realization in a seed table with one row per event. Every event of a realization
+// shares the realization and block seeds, so a realization starts at the first event row where either seed changes.
+func RealizationBlockSeeds(seeds []SeedSet) []int64 {
+	blockSeeds := make([]int64, 0)
+	for i, s := range seeds {
+		if i == 0 || s.RealizationSeed != seeds[i-1].RealizationSeed || s.BlockSeed != seeds[i-1].BlockSeed {
+			blockSeeds = append(blockSeeds, s.BlockSeed)
+		}
+	}
+	return blockSeeds
+}
@@ -1,26 +1,61 @@
 package utils
 
 import (
-	"fmt"
 	"testing"
-
-	"github.com/usace-cloud-compute/cc-go-sdk"
-	tiledb "github.com/usace-cloud-compute/cc-go-sdk/tiledb-store"
 )
 
-func TestReadSeedsFromTDB(t *testing.T) {
-	//register tiledb
-	cc.DataStoreTypeRegistry.Register("TILEDB", tiledb.TileDbEventStore{})
-	pm, err := cc.InitPluginManager()
-	if err != nil {
-		fmt.Println(err)
-		t.Fail()
+func TestGenerateRealizationSeeds(t *testing.T) {
+	blocks := []Block{
+		{RealizationIndex: 1, BlockIndex: 1, BlockEventCount: 2, BlockEventStart: 1, BlockEventEnd: 2},
+		{RealizationIndex: 1, BlockIndex: 2, BlockEventCount: 0, BlockEventStart: 3, BlockEventEnd: 2},
+		{RealizationIndex: 2, BlockIndex: 1, BlockEventCount: 3, BlockEventStart: 3, BlockEventEnd: 5},
+	}
+	blockSeeds := []int64{11, 22}
+	seeds := GenerateRealizationSeeds(1234, blockSeeds, blocks)
+	if len(seeds) != 5 {
+		t.Fatalf("expected 5 seed sets got %v", len(seeds))
+	}
+	if seeds[0].RealizationSeed != seeds[1].RealizationSeed || seeds[1].RealizationSeed == seeds[2].RealizationSeed {
+		t.Error("events should share a realization seed only within a realization")
 	}
+	if seeds[1].BlockSeed != 11 || seeds[4].BlockSeed != 22 {
+		t.Error("events should carry the block seed of their realization")
+	}
+	again := GenerateRealizationSeeds(1234, blockSeeds, blocks)
+	for i := range seeds {
+		if seeds[i] != again[i] {
+			t.Fatal("seed generation is not reproducible")
+		}
+	}
+}
 
-	seeds, err := ReadSeedsFromTiledb(pm.IOManager, "store", "seeds", "hms-mutator")
+func TestRealizationBlockSeeds(t *testing.T) {
+	blocks := []Block{
+		{RealizationIndex: 1, BlockIndex: 1, BlockEventCount: 2, BlockEventStart: 1, BlockEventEnd: 2},
+		{RealizationIndex: 2, BlockIndex: 1, BlockEventCount: 3, BlockEventStart: 3, BlockEventEnd: 5},
+		{RealizationIndex: 3, BlockIndex: 1, BlockEventCount: 1, BlockEventStart: 6, BlockEventEnd: 6},
+	}
+	seeds := GenerateRealizationSeeds(1234, []int64{11, 22, 33}, blocks)
+	blockSeeds := RealizationBlockSeeds(seeds)
+	if len(blockSeeds) != 3 || blockSeeds[0] != 11 || blockSeeds[1] != 22 || blockSeeds[2] != 33 {
+		t.Fatalf("expected the block seeds 11, 22 and 33 from the first event row of each realization got %v", blockSeeds)
+	}
+	//each realization is generated from its own block seed so two realizations have different blocks.
+	model, err := ArrivalModelFromAttributes(map[string]any{"ST1": map[string]any{"distribution": "poisson", "mean": 1.2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, _, err := GenerateBlocks(model, blockSeeds[:2], 50)
 	if err != nil {
-		fmt.Println(err)
-		t.Fail()
+		t.Fatal(err)
+	}
+	same := true
+	for b := 0; b < 50; b++ {
+		if generated[b].BlockEventCount != generated[50+b].BlockEventCount {
+			same = false
+		}
+	}
+	if same {
+		t.Error("expected two realizations to produce different blocks")
 	}
-	fmt.Println(seeds)
 }
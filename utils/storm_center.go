@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	filestore "github.com/usace-cloud-compute/filesapi"
+)
+
+// StormCenterMethod is how a storm center is derived from a precipitation field.
+type StormCenterMethod string
+
+const (
+	CentroidStormCenter StormCenterMethod = "centroid"  //depth weighted centroid of the total depth field.
+	MaxDepthStormCenter StormCenterMethod = "max_depth" //cell with the maximum depth over a window of time steps.
+)
+
+// PrecipitationField is a sequence of precipitation grids (one per time step) on the same raster, values are row major.
+type PrecipitationField struct {
+	GeoTransform [6]float64
+	XSize        int
+	YSize        int
+	NoData       float64
+	HasNoData    bool
+	Projection   string //well known text, empty if unknown.
+	Steps        [][]float64
+}
+
+// valid is true if a cell value is data, nodata, NaN and negative values are excluded.
+func (pf PrecipitationField) valid(v float64) bool {
+	if math.IsNaN(v) || v < 0 {
+		return false
+	}
+	return !(pf.HasNoData && v == pf.NoData)
+}
+
+// cellCenter is the coordinate of the center of a cell.
+func (pf PrecipitationField) cellCenter(idx int) Coordinate {
+	col := float64(idx%pf.XSize) + .5
+	row := float64(idx/pf.XSize) + .5
+	gt := pf.GeoTransform
+	return Coordinate{
+		X: gt[0] + col*gt[1] + row*gt[2],
+		Y: gt[3] + col*gt[4] + row*gt[5],
+	}
+}
+func (pf PrecipitationField) validate() error {
+	if len(pf.Steps) == 0 {
+		return errors.New("the precipitation field has no time steps")
+	}
+	for i, s := range pf.Steps {
+		if len(s) != pf.XSize*pf.YSize {
+			return fmt.Errorf("time step %v has %v cells expected %v", i, len(s), pf.XSize*pf.YSize)
+		}
+	}
+	return nil
+}
+
+// DepthWeightedCentroid is the centroid of the cell centers weighted by the total depth of each cell.
+func (pf PrecipitationField) DepthWeightedCentroid() (Coordinate, error) {
+	if err := pf.validate(); err != nil {
+		return Coordinate{}, err
+	}
+	total := 0.0
+	x, y := 0.0, 0.0
+	for idx := 0; idx < pf.XSize*pf.YSize; idx++ {
+		depth := 0.0
+		for _, s := range pf.Steps {
+			if pf.valid(s[idx]) {
+				depth += s[idx]
+			}
+		}
+		if depth == 0 {
+			continue
+		}
+		c := pf.cellCenter(idx)
+		x += depth * c.X
+		y += depth * c.Y
+		total += depth
+	}
+	if total == 0 {
+		return Coordinate{}, errors.New("the precipitation field has no depth")
+	}
+	return Coordinate{X: x / total, Y: y / total}, nil
+}
+
+// MaxDepthCenter is the center of the cell with the greatest depth accumulated over windowSteps consecutive time steps,
+// ties are broken by the first cell in row major order. If windowSteps is less than 1 or longer than the storm the total depth is used.
+func (pf PrecipitationField) MaxDepthCenter(windowSteps int) (Coordinate, error) {
+	if err := pf.validate(); err != nil {
+		return Coordinate{}, err
+	}
+	if windowSteps < 1 || windowSteps > len(pf.Steps) {
+		windowSteps = len(pf.Steps)
+	}
+	best := -1
+	bestDepth := 0.0
+	for idx := 0; idx < pf.XSize*pf.YSize; idx++ {
+		window := 0.0
+		maxWindow := 0.0
+		for i, s := range pf.Steps {
+			if pf.valid(s[idx]) {
+				window += s[idx]
+			}
+			if i >= windowSteps {
+				if old := pf.Steps[i-windowSteps][idx]; pf.valid(old) {
+					window -= old
+				}
+			}
+			if i >= windowSteps-1 && window > maxWindow {
+				maxWindow = window
+			}
+		}
+		if maxWindow > bestDepth {
+			best = idx
+			bestDepth = maxWindow
+		}
+	}
+	if best < 0 {
+		return Coordinate{}, errors.New("the precipitation field has no depth")
+	}
+	return pf.cellCenter(best), nil
+}
+
+// StormCenter derives a storm center with the method, windowSteps only applies to MaxDepthStormCenter.
+func (pf PrecipitationField) StormCenter(method StormCenterMethod, windowSteps int) (Coordinate, error) {
+	switch method {
+	case CentroidStormCenter:
+		return pf.DepthWeightedCentroid()
+	case MaxDepthStormCenter:
+		return pf.MaxDepthCenter(windowSteps)
+	default:
+		return Coordinate{}, fmt.Errorf("%v is not a storm center method, use %v or %v", method, CentroidStormCenter, MaxDepthStormCenter)
+	}
+}
+
+// StormCenters is a map of storm name to storm center.
+type StormCenters map[string]Coordinate
+
+// StormCentersFromBytes reads a csv with a header and storm_name,x,y rows.
+func StormCentersFromBytes(data []byte) (StormCenters, error) {
+	centers := make(StormCenters)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if i == 0 || len(strings.TrimSpace(line)) == 0 {
+			continue //skip header and empty lines
+		}
+		vals := strings.Split(line, ",")
+		if len(vals) < 3 {
+			return centers, fmt.Errorf("storm centers line %v does not have a storm name, x and y", i+1)
+		}
+		name := strings.TrimSpace(vals[0])
+		x, err := strconv.ParseFloat(strings.TrimSpace(vals[1]), 64)
+		if err != nil {
+			return centers, fmt.Errorf("could not parse x for storm %v: %v", name, err)
+		}
+		y, err := strconv.ParseFloat(strings.TrimSpace(vals[2]), 64)
+		if err != nil {
+			return centers, fmt.Errorf("could not parse y for storm %v: %v", name, err)
+		}
+		if _, ok := centers[name]; ok {
+			return centers, fmt.Errorf("storm %v is listed more than once in the storm centers", name)
+		}
+		centers[name] = Coordinate{X: x, Y: y}
+	}
+	return centers, nil
+}
+
+// Center finds the center for a storm by name, if the exact name is not present the name without its extension is tried.
+func (sc StormCenters) Center(stormName string) (Coordinate, bool) {
+	c, ok := sc[stormName]
+	if ok {
+		return c, ok
+	}
+	c, ok = sc[strings.TrimSuffix(stormName, path.Ext(stormName))]
+	return c, ok
+}
+func ReadStormCenters(iomanager cc.IOManager, storeKey string, filePath string) (StormCenters, error) {
+	store, err := iomanager.GetStore(storeKey)
+	if err != nil {
+		return nil, err
+	}
+	session, ok := store.Session.(*cc.FileDataStore[filestore.S3FS])
+	if !ok {
+		return nil, fmt.Errorf("%v was not an s3datastore type", storeKey)
+	}
+	root := store.Parameters.GetStringOrFail("root")
+	pathpart := strings.Replace(filePath, fmt.Sprintf("%v/", root), "", -1)
+	reader, err := session.Get(pathpart, "")
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return StormCentersFromBytes(bytes)
+}
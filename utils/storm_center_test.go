@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+// testPrecipitationField is a 3x2 field with 10 unit cells and the origin at the upper left corner.
+func testPrecipitationField(steps ...[]float64) PrecipitationField {
+	return PrecipitationField{
+		GeoTransform: [6]float64{0, 10, 0, 20, 0, -10},
+		XSize:        3,
+		YSize:        2,
+		NoData:       -9999,
+		HasNoData:    true,
+		Steps:        steps,
+	}
+}
+func TestDepthWeightedCentroid(t *testing.T) {
+	pf := testPrecipitationField(
+		[]float64{1, 0, 0, 0, 0, -9999},
+		[]float64{0, 0, 3, 0, 0, -9999},
+	)
+	c, err := pf.StormCenter(CentroidStormCenter, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c.X-20) > 1e-9 || math.Abs(c.Y-15) > 1e-9 {
+		t.Errorf("expected the centroid at 20,15 got %v,%v", c.X, c.Y)
+	}
+	_, err = testPrecipitationField([]float64{0, 0, 0, 0, 0, 0}).DepthWeightedCentroid()
+	if err == nil {
+		t.Error("expected an error for a field without depth")
+	}
+}
+func TestMaxDepthCenter(t *testing.T) {
+	pf := testPrecipitationField(
+		[]float64{2, 0, 0, 0, 1, 0},
+		[]float64{2, 0, 0, 0, 0, 0},
+		[]float64{0, 0, 0, 0, 3, 0},
+		[]float64{0, 0, 0, 0, 3, 0},
+	)
+	c, err := pf.StormCenter(MaxDepthStormCenter, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.X != 15 || c.Y != 5 {
+		t.Errorf("expected the two step maximum at 15,5 got %v,%v", c.X, c.Y)
+	}
+	c, _ = pf.MaxDepthCenter(1)
+	if c.X != 15 || c.Y != 5 {
+		t.Errorf("expected the one step maximum at 15,5 got %v,%v", c.X, c.Y)
+	}
+	pf.Steps[2][4], pf.Steps[3][4] = 1, 1
+	c, _ = pf.MaxDepthCenter(2)
+	if c.X != 5 || c.Y != 15 {
+		t.Errorf("expected the two step maximum at 5,15 got %v,%v", c.X, c.Y)
+	}
+	c, _ = pf.MaxDepthCenter(0)
+	if c.X != 5 || c.Y != 15 {
+		t.Errorf("expected the total depth maximum at 5,15 got %v,%v", c.X, c.Y)
+	}
+	_, err = pf.StormCenter("peak", 1)
+	if err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+	pf.Steps[1] = pf.Steps[1][:3]
+	_, err = pf.MaxDepthCenter(1)
+	if err == nil {
+		t.Error("expected an error for a time step with the wrong number of cells")
+	}
+}
+func TestStormCentersFromBytes(t *testing.T) {
+	data := []byte("storm_name,x,y\r\n19790205_72hr_st1_r01,100.5,200\r\n19800306_72hr_st2_r02.dss,300,-400\r\n")
+	centers, err := StormCentersFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := centers.Center("19790205_72hr_st1_r01.dss")
+	if !ok || c.X != 100.5 || c.Y != 200 {
+		t.Errorf("expected 100.5,200 for the storm without an extension got %v %v", c, ok)
+	}
+	c, ok = centers.Center("19800306_72hr_st2_r02.dss")
+	if !ok || c.X != 300 || c.Y != -400 {
+		t.Errorf("expected 300,-400 got %v %v", c, ok)
+	}
+	if _, ok = centers.Center("19810407_72hr_st3_r03.dss"); ok {
+		t.Error("expected no center for a storm that is not listed")
+	}
+	for _, invalid := range []string{"storm_name,x,y\nstorm,1\n", "storm_name,x,y\nstorm,a,1\n", "storm_name,x,y\nstorm,1,1\nstorm,2,2\n"} {
+		if _, err = StormCentersFromBytes([]byte(invalid)); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
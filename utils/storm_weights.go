@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	filestore "github.com/usace-cloud-compute/filesapi"
+)
+
+// StormWeights is a map of storm name to a probability weight, weights are relative and are normalized over the catalog they are applied to.
+type StormWeights map[string]float64
+
+// StormWeightsFromBytes reads a csv with a header and storm_name,weight rows.
+func StormWeightsFromBytes(data []byte) (StormWeights, error) {
+	weights := make(StormWeights)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if i == 0 || len(strings.TrimSpace(line)) == 0 {
+			continue //skip header and empty lines
+		}
+		vals := strings.Split(line, ",")
+		if len(vals) < 2 {
+			return weights, fmt.Errorf("storm weights line %v does not have a storm name and weight", i+1)
+		}
+		name := strings.TrimSpace(vals[0])
+		weight, err := strconv.ParseFloat(strings.TrimSpace(vals[1]), 64)
+		if err != nil {
+			return weights, fmt.Errorf("could not parse weight for storm %v: %v", name, err)
+		}
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return weights, fmt.Errorf("storm %v has an invalid weight %v, weights must be finite and non-negative", name, weight)
+		}
+		if _, ok := weights[name]; ok {
+			return weights, fmt.Errorf("storm %v is listed more than once in the storm weights", name)
+		}
+		weights[name] = weight
+	}
+	if len(weights) == 0 {
+		return weights, fmt.Errorf("no storm weights were found")
+	}
+	return weights, nil
+}
+
+// Weight finds the weight for a storm by name, if the exact name is not present the name without its extension is tried.
+func (sw StormWeights) Weight(stormName string) (float64, bool) {
+	w, ok := sw[stormName]
+	if ok {
+		return w, ok
+	}
+	w, ok = sw[strings.TrimSuffix(stormName, path.Ext(stormName))]
+	return w, ok
+}
+
+// Normalize produces weights aligned with the storm names provided that sum to one.
+// every storm must have a weight and at least one weight must be positive.
+func (sw StormWeights) Normalize(stormNames []string) ([]float64, error) {
+	normalized := make([]float64, len(stormNames))
+	total := 0.0
+	for i, name := range stormNames {
+		w, ok := sw.Weight(name)
+		if !ok {
+			return normalized, fmt.Errorf("storm %v does not have a weight in the storm weights", name)
+		}
+		normalized[i] = w
+		total += w
+	}
+	if total <= 0 {
+		return normalized, fmt.Errorf("storm weights must sum to a positive value")
+	}
+	for i := range normalized {
+		normalized[i] = normalized[i] / total
+	}
+	return normalized, nil
+}
+
+// Distribution normalizes the weights for the storm names and returns a distribution to sample storm indexes from.
+func (sw StormWeights) Distribution(stormNames []string) (WeightedIndexDistribution, error) {
+	normalized, err := sw.Normalize(stormNames)
+	if err != nil {
+		return WeightedIndexDistribution{}, err
+	}
+	return NewWeightedIndexDistribution(normalized), nil
+}
+
+// WeightedIndexDistribution samples an index with probability proportional to normalized weights.
+type WeightedIndexDistribution struct {
+	weights                []float64
+	cumulative_probability []float64
+}
+
+func NewWeightedIndexDistribution(normalizedWeights []float64) WeightedIndexDistribution {
+	cumulative := make([]float64, len(normalizedWeights))
+	running := 0.0
+	for i, w := range normalizedWeights {
+		running += w
+		cumulative[i] = running
+	}
+	return WeightedIndexDistribution{weights: normalizedWeights, cumulative_probability: cumulative}
+}
+
+// Sample returns the index associated with the probability, zero weighted indexes are never returned.
+func (wid WeightedIndexDistribution) Sample(probability float64) int {
+	for i, p := range wid.cumulative_probability {
+		if p > probability && wid.weights[i] > 0 {
+			return i
+		}
+	}
+	//guard against round off in the cumulative sum, return the last index with weight.
+	for i := len(wid.weights) - 1; i >= 0; i-- {
+		if wid.weights[i] > 0 {
+			return i
+		}
+	}
+	return len(wid.weights) - 1
+}
+
+// Weight returns the normalized weight of an index.
+func (wid WeightedIndexDistribution) Weight(index int) float64 {
+	return wid.weights[index]
+}
+func ReadStormWeights(iomanager cc.IOManager, storeKey string, filePath string) (StormWeights, error) {
+	store, err := iomanager.GetStore(storeKey)
+	if err != nil {
+		return nil, err
+	}
+	session, ok := store.Session.(*cc.FileDataStore[filestore.S3FS])
+	if !ok {
+		return nil, fmt.Errorf("%v was not an s3datastore type", storeKey)
+	}
+	root := store.Parameters.GetStringOrFail("root")
+	pathpart := strings.Replace(filePath, fmt.Sprintf("%v/", root), "", -1)
+	reader, err := session.Get(pathpart, "")
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return StormWeightsFromBytes(bytes)
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestStormWeights(t *testing.T) {
+	data := []byte("storm_name,weight\r\n19790205_72hr_st1_r01,2\r\n19800306_72hr_st2_r02.dss,1\r\n19810407_72hr_st3_r03,0\r\n")
+	weights, err := StormWeightsFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"19790205_72hr_st1_r01.dss", "19800306_72hr_st2_r02.dss", "19810407_72hr_st3_r03.dss"}
+	normalized, err := weights.Normalize(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []float64{2.0 / 3.0, 1.0 / 3.0, 0}
+	for i, w := range normalized {
+		if math.Abs(w-expected[i]) > 1e-12 {
+			t.Errorf("expected %v for %v got %v", expected[i], names[i], w)
+		}
+	}
+	dist, err := weights.Distribution(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1234))
+	counts := make([]int, len(names))
+	for i := 0; i < 10000; i++ {
+		counts[dist.Sample(rng.Float64())]++
+	}
+	if counts[2] != 0 {
+		t.Errorf("sampled a zero weighted storm %v times", counts[2])
+	}
+	if math.Abs(float64(counts[0])/10000-expected[0]) > .02 {
+		t.Errorf("expected about %v of samples for %v got %v", expected[0], names[0], float64(counts[0])/10000)
+	}
+	_, err = weights.Normalize(append(names, "19820508_72hr_st4_r04.dss"))
+	if err == nil {
+		t.Error("expected an error for a storm without a weight")
+	}
+	for _, invalid := range []string{"storm_name,weight\nstorm,-1\n", "storm_name,weight\nstorm,NaN\n", "storm_name,weight\nstorm,1\nstorm,2\n", "storm_name,weight\n"} {
+		_, err = StormWeightsFromBytes([]byte(invalid))
+		if err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
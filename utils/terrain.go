@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/dewberry/gdal"
+)
+
+// TerrainConstraint is an optional physiographic acceptance test for a placement. A placement is rejected if the mean elevation under the
+// watershed differs from the mean elevation of the area the storm covered at its source location (the watershed shifted by the inverse
+// of the transposition) by more than MaxElevationDifference, or if the path from the source storm center to the placement crosses a barrier.
+type TerrainConstraint struct {
+	Elevation              DepthRaster //elevation raster held in memory, only used if HasElevation.
+	HasElevation           bool
+	MaxElevationDifference float64        //elevation is not constrained if not positive.
+	Barriers               [][]Coordinate //polylines such as ridge lines.
+	footprint              WatershedFootprint
+	watershedElevation     float64
+}
+
+// Enabled is true if the constraint can reject a placement.
+func (tc TerrainConstraint) Enabled() bool {
+	return tc.constrainsElevation() || len(tc.Barriers) > 0
+}
+func (tc TerrainConstraint) constrainsElevation() bool {
+	return tc.HasElevation && tc.MaxElevationDifference > 0
+}
+
+// WithWatershed returns the constraint for a watershed footprint on the elevation raster grid, every watershed cell must have an elevation.
+func (tc TerrainConstraint) WithWatershed(footprint WatershedFootprint) (TerrainConstraint, error) {
+	if !tc.constrainsElevation() {
+		return tc, nil
+	}
+	if len(footprint.Cells) == 0 {
+		return tc, errors.New("an elevation constraint requires a watershed footprint")
+	}
+	mean, missing := tc.Elevation.MeanOver(footprint.Cells, 0, 0)
+	if missing > 0 {
+		return tc, fmt.Errorf("%v of %v watershed cells do not have an elevation", missing, len(footprint.Cells))
+	}
+	tc.footprint = footprint
+	tc.watershedElevation = mean
+	return tc, nil
+}
+
+// ForWatershed returns the terrain constraint for the watershed, the watershed footprint is computed on the elevation raster grid.
+func (tc TerrainConstraint) ForWatershed(watershed Domain) (TerrainConstraint, error) {
+	if !tc.constrainsElevation() {
+		return tc, nil
+	}
+	footprint, err := NewWatershedFootprint(watershed, tc.Elevation)
+	if err != nil {
+		return tc, err
+	}
+	return tc.WithWatershed(footprint)
+}
+
+// ElevationDifference is the absolute difference between the mean elevation under the watershed and the mean elevation of the watershed
+// shifted to the source storm location, NaN if part of the shifted watershed does not have an elevation.
+func (tc TerrainConstraint) ElevationDifference(stormCenter Coordinate, placement Coordinate) float64 {
+	source, missing := tc.Elevation.MeanOver(tc.footprint.Cells, stormCenter.X-placement.X, stormCenter.Y-placement.Y)
+	if missing > 0 {
+		return math.NaN()
+	}
+	return math.Abs(source - tc.watershedElevation)
+}
+
+// Accept is true if the storm centered at stormCenter can be placed at placement.
+func (tc TerrainConstraint) Accept(stormCenter Coordinate, placement Coordinate) bool {
+	if tc.constrainsElevation() {
+		difference := tc.ElevationDifference(stormCenter, placement)
+		if math.IsNaN(difference) || difference > tc.MaxElevationDifference {
+			return false
+		}
+	}
+	return !CrossesBarrier(stormCenter, placement, tc.Barriers)
+}
+
+// MeanOver is the mean of the raster at each cell shifted by dx and dy, and the number of cells outside the raster or nodata.
+func (dr DepthRaster) MeanOver(cells []Coordinate, dx float64, dy float64) (float64, int) {
+	missing := 0
+	total := 0.0
+	for _, c := range cells {
+		idx := dr.pixel(c.X+dx, c.Y+dy)
+		if idx < 0 {
+			missing++
+			continue
+		}
+		v := dr.Values[idx]
+		if math.IsNaN(v) || (dr.HasNoData && v == dr.NoData) {
+			missing++
+			continue
+		}
+		total += v
+	}
+	count := len(cells) - missing
+	if count == 0 {
+		return math.NaN(), missing
+	}
+	return total / float64(count), missing
+}
+
+// ReadBarriers reads the selected line features (for example ridge lines) as polylines, polygon features contribute their rings.
+func ReadBarriers(ds gdal.DataSource, selection DomainSelection) ([][]Coordinate, error) {
+	barriers := make([][]Coordinate, 0)
+	layer, err := selectLayer(ds, selection.Layer)
+	if err != nil {
+		return barriers, err
+	}
+	if selection.Filter != "" {
+		err = layer.SetAttributeFilter(selection.Filter)
+		if err != nil {
+			return barriers, fmt.Errorf("could not apply the filter %v to layer %v: %v", selection.Filter, layer.Name(), err)
+		}
+		defer layer.SetAttributeFilter("")
+	}
+	layer.ResetReading()
+	defer layer.ResetReading()
+	for feature := layer.NextFeature(); feature != nil; feature = layer.NextFeature() {
+		barriers = appendPolylines(barriers, feature.Geometry())
+		feature.Destroy()
+	}
+	if len(barriers) == 0 {
+		return barriers, fmt.Errorf("no barriers were selected from layer %v", layer.Name())
+	}
+	return barriers, nil
+}
+
+// appendPolylines appends the points of every line and ring of the geometry.
+func appendPolylines(polylines [][]Coordinate, geometry gdal.Geometry) [][]Coordinate {
+	count := geometry.GeometryCount()
+	if count == 0 {
+		if geometry.PointCount() > 1 {
+			line := make([]Coordinate, geometry.PointCount())
+			for i := range line {
+				x, y, _ := geometry.Point(i)
+				line[i] = Coordinate{X: x, Y: y}
+			}
+			polylines = append(polylines, line)
+		}
+		return polylines
+	}
+	for g := 0; g < count; g++ {
+		polylines = appendPolylines(polylines, geometry.Geometry(g))
+	}
+	return polylines
+}
+
+// CrossesBarrier is true if the segment from a to b touches or crosses any segment of the barrier polylines.
+func CrossesBarrier(a Coordinate, b Coordinate, barriers [][]Coordinate) bool {
+	for _, barrier := range barriers {
+		for i := 1; i < len(barrier); i++ {
+			if segmentsIntersect(a, b, barrier[i-1], barrier[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orientation is positive if c is left of the line from a to b, negative if it is right and zero if the points are collinear.
+func orientation(a Coordinate, b Coordinate, c Coordinate) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// onSegment is true if c, collinear with a and b, is within the bounding box of a and b.
+func onSegment(a Coordinate, b Coordinate, c Coordinate) bool {
+	return math.Min(a.X, b.X) <= c.X && c.X <= math.Max(a.X, b.X) && math.Min(a.Y, b.Y) <= c.Y && c.Y <= math.Max(a.Y, b.Y)
+}
+
+// segmentsIntersect is true if segment p1p2 and segment q1q2 share a point.
+func segmentsIntersect(p1 Coordinate, p2 Coordinate, q1 Coordinate, q2 Coordinate) bool {
+	d1 := orientation(q1, q2, p1)
+	d2 := orientation(q1, q2, p2)
+	d3 := orientation(p1, p2, q1)
+	d4 := orientation(p1, p2, q2)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(q1, q2, p1)) || (d2 == 0 && onSegment(q1, q2, p2)) ||
+		(d3 == 0 && onSegment(p1, p2, q1)) || (d4 == 0 && onSegment(p1, p2, q2))
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTerrainConstraint(t *testing.T) {
+	//a 4x2 elevation raster with 10 unit cells rising to the east, the last cell is nodata.
+	elevation := DepthRaster{
+		GeoTransform: [6]float64{0, 10, 0, 20, 0, -10},
+		XSize:        4,
+		YSize:        2,
+		NoData:       -9999,
+		HasNoData:    true,
+		Values:       []float64{100, 200, 300, 400, 100, 200, 300, -9999},
+	}
+	//a watershed covering the two western cells of the top row.
+	footprint := WatershedFootprint{Cells: []Coordinate{{X: 5, Y: 15}, {X: 15, Y: 15}}, CellArea: 100}
+	tc, err := TerrainConstraint{Elevation: elevation, HasElevation: true, MaxElevationDifference: 150}.WithWatershed(footprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//a storm centered 10 east of the placement covers cells of 200 and 300 at its source, a difference of 100.
+	if d := tc.ElevationDifference(Coordinate{X: 20, Y: 10}, Coordinate{X: 10, Y: 10}); d != 100 {
+		t.Errorf("expected an elevation difference of 100 got %v", d)
+	}
+	if !tc.Accept(Coordinate{X: 20, Y: 10}, Coordinate{X: 10, Y: 10}) {
+		t.Error("expected the placement within the elevation difference to be accepted")
+	}
+	if tc.Accept(Coordinate{X: 30, Y: 10}, Coordinate{X: 10, Y: 10}) {
+		t.Error("expected the placement with an elevation difference of 200 to be rejected")
+	}
+	//the bottom row shifted 20 east includes nodata.
+	if d := tc.ElevationDifference(Coordinate{X: 30, Y: 0}, Coordinate{X: 10, Y: 10}); !math.IsNaN(d) {
+		t.Errorf("expected a NaN difference over nodata got %v", d)
+	}
+	if tc.Accept(Coordinate{X: 30, Y: 0}, Coordinate{X: 10, Y: 10}) {
+		t.Error("expected the placement over nodata to be rejected")
+	}
+	//a north south ridge at x=15.
+	tc.Barriers = [][]Coordinate{{{X: 15, Y: -100}, {X: 15, Y: 0}, {X: 15, Y: 100}}}
+	if tc.Accept(Coordinate{X: 20, Y: 10}, Coordinate{X: 10, Y: 10}) {
+		t.Error("expected the placement across the ridge to be rejected")
+	}
+	if !tc.Accept(Coordinate{X: 10, Y: 0}, Coordinate{X: 10, Y: 10}) {
+		t.Error("expected the placement on the same side of the ridge to be accepted")
+	}
+	if _, err = tc.WithWatershed(WatershedFootprint{Cells: []Coordinate{{X: 35, Y: 5}}}); err == nil {
+		t.Error("expected an error for a watershed without elevation")
+	}
+	if (TerrainConstraint{}).Enabled() || !(TerrainConstraint{Barriers: tc.Barriers}).Enabled() {
+		t.Error("expected only constraints with an elevation difference or barriers to be enabled")
+	}
+}
+
+func TestCrossesBarrier(t *testing.T) {
+	barrier := [][]Coordinate{{{X: 0, Y: 0}, {X: 10, Y: 10}}}
+	cases := []struct {
+		a, b    Coordinate
+		crosses bool
+	}{
+		{Coordinate{X: 0, Y: 10}, Coordinate{X: 10, Y: 0}, true},
+		{Coordinate{X: 0, Y: 1}, Coordinate{X: 5, Y: 6}, false},     //parallel
+		{Coordinate{X: 5, Y: 5}, Coordinate{X: 5, Y: 9}, true},      //touches
+		{Coordinate{X: 11, Y: 11}, Coordinate{X: 12, Y: 12}, false}, //collinear beyond the end
+		{Coordinate{X: 9, Y: 9}, Coordinate{X: 12, Y: 12}, true},    //collinear overlap
+	}
+	for _, c := range cases {
+		if CrossesBarrier(c.a, c.b, barrier) != c.crosses {
+			t.Errorf("expected crosses to be %v for %v to %v", c.crosses, c.a, c.b)
+		}
+	}
+}
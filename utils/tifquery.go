@@ -29,6 +29,87 @@ func InitTifReader(fp string) (TifReader, error) {
 	}
 	return cr, nil
 }
+
+// ReadPrecipitationField reads every band of a raster as a time step of a precipitation field, bands are expected in time order.
+func ReadPrecipitationField(fp string) (PrecipitationField, error) {
+	ds, err := gdal.Open(fp, gdal.Access(gdal.Read))
+	if err != nil {
+		return PrecipitationField{}, errors.New("Cannot connect to raster at path " + fp + err.Error())
+	}
+	defer ds.Close()
+	pf := PrecipitationField{
+		GeoTransform: ds.GeoTransform(),
+		XSize:        ds.RasterXSize(),
+		YSize:        ds.RasterYSize(),
+		Projection:   ds.Projection(),
+	}
+	for b := 1; b <= ds.RasterCount(); b++ {
+		rb := ds.RasterBand(b)
+		if b == 1 {
+			pf.NoData, pf.HasNoData = rb.NoDataValue()
+		}
+		buffer := make([]float32, pf.XSize*pf.YSize)
+		err = rb.IO(gdal.RWFlag(gdal.Read), 0, 0, pf.XSize, pf.YSize, buffer, pf.XSize, pf.YSize, 0, 0)
+		if err != nil {
+			return pf, err
+		}
+		step := make([]float64, len(buffer))
+		for i, v := range buffer {
+			step[i] = float64(v)
+		}
+		pf.Steps = append(pf.Steps, step)
+	}
+	if pf.HasNoData {
+		pf.NoData = float64(float32(pf.NoData)) //values were read as float32.
+	}
+	return pf, nil
+}
+
+// ReadElevationRaster reads the first band of an elevation raster (a DEM) into memory, nodata cells are NaN.
+func ReadElevationRaster(fp string) (DepthRaster, error) {
+	field, err := ReadPrecipitationField(fp)
+	if err != nil {
+		return DepthRaster{}, err
+	}
+	if len(field.Steps) == 0 {
+		return DepthRaster{}, errors.New("the elevation raster at " + fp + " does not have a band")
+	}
+	field.Steps = field.Steps[:1]
+	return field.TotalDepth()
+}
+
+// WriteDepthRaster writes a single band float64 GeoTIFF to a local path.
+func WriteDepthRaster(dr DepthRaster, fp string) error {
+	driver, err := gdal.GetDriverByName("GTiff")
+	if err != nil {
+		return err
+	}
+	ds := driver.Create(fp, dr.XSize, dr.YSize, 1, gdal.DataType(gdal.Float64), nil)
+	defer ds.Close()
+	err = ds.SetGeoTransform(dr.GeoTransform)
+	if err != nil {
+		return err
+	}
+	if dr.Projection != "" {
+		err = ds.SetProjection(dr.Projection)
+		if err != nil {
+			return err
+		}
+	}
+	rb := ds.RasterBand(1)
+	if dr.HasNoData {
+		err = rb.SetNoDataValue(dr.NoData)
+		if err != nil {
+			return err
+		}
+	}
+	err = rb.IO(gdal.RWFlag(gdal.Write), 0, 0, dr.XSize, dr.YSize, dr.Values, dr.XSize, dr.YSize, 0, 0)
+	if err != nil {
+		return err
+	}
+	ds.FlushCache()
+	return nil
+}
 func (cr *TifReader) Close() {
 	cr.ds.Close()
 }
@@ -0,0 +1,49 @@
+//go:build tiledb
+
+// The tiledb tests read seeds and blocks from the store of a cc payload, run them with -tags tiledb in an environment with a payload.
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/usace-cloud-compute/cc-go-sdk"
+	tiledb "github.com/usace-cloud-compute/cc-go-sdk/tiledb-store"
+)
+
+func TestReadSeedsFromTDB(t *testing.T) {
+	//register tiledb
+	cc.DataStoreTypeRegistry.Register("TILEDB", tiledb.TileDbEventStore{})
+	pm, err := cc.InitPluginManager()
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	store, err := pm.IOManager.GetStore("store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seeds, err := NewTileDbSeedReader(store, "seeds", "hms-mutator").Read()
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	fmt.Println(seeds)
+}
+
+func TestReadBlocksFromTDB(t *testing.T) {
+	//register tiledb
+	cc.DataStoreTypeRegistry.Register("TILEDB", tiledb.TileDbEventStore{})
+	pm, err := cc.InitPluginManager()
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+
+	blocks, err := NewTileDbBlockReader(pm, "store", "blocks").Read()
+	if err != nil {
+		fmt.Println(err)
+		t.Fail()
+	}
+	fmt.Println(blocks)
+}